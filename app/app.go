@@ -0,0 +1,65 @@
+// Package app runs the game in a window with Ebiten. It passes the input of
+// the keyboard, the mouse, touch screens and gamepads to the game, draws its
+// frames with package view, and gives it the clipboard, sound and the window.
+package app
+
+import (
+	"zenmojo/audio"
+	"zenmojo/clock"
+	"zenmojo/config"
+	"zenmojo/game"
+	"zenmojo/layout"
+	"zenmojo/view"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// App is the game as it runs in the window.
+type App struct {
+	game  *game.Game
+	clock *clock.Ticks
+}
+
+// New creates the game and connects it to Ebiten. The game runs at
+// config.TPS ticks per second, and its clock advances with every tick.
+func New(audioManager *audio.Manager) *App {
+	ebiten.SetTPS(config.TPS)
+	a := &App{clock: clock.NewTicks(config.TPS)}
+	a.game = game.NewGame(game.Platform{
+		Input:     &input{},
+		Screen:    viewScreen{},
+		Clipboard: systemClipboard{},
+		Sound:     audioManager,
+		Window:    ebitenWindow{},
+		Clock:     a.clock,
+	})
+	return a
+}
+
+// Update proceeds the game by one tick. Closing the window ends the game and
+// saves the window geometry for the next run.
+func (a *App) Update() error {
+	if ebiten.IsWindowBeingClosed() {
+		a.game.Close()
+		saveWindow()
+		return ebiten.Termination
+	}
+	a.clock.Tick()
+	a.game.Update()
+	return nil
+}
+
+// Draw renders the game screen.
+func (a *App) Draw(screen *ebiten.Image) {
+	view.DrawFrame(screen, a.game.Frame())
+}
+
+// Layout is called when the window is resized. It arranges the screen for the
+// window, see layout.Fit. The canvas has one pixel per physical pixel of the
+// window, so that the game stays sharp on high-DPI monitors.
+func (a *App) Layout(outsideWidth, outsideHeight int) (int, int) {
+	scale := ebiten.Monitor().DeviceScaleFactor()
+	l := layout.Fit(int(float64(outsideWidth)*scale), int(float64(outsideHeight)*scale))
+	a.game.SetLayout(l)
+	return l.Width, l.Height
+}
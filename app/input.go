@@ -0,0 +1,95 @@
+package app
+
+import (
+	"log"
+	"zenmojo/game"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// input reads the keyboard, the mouse, touch screens and gamepads from Ebiten,
+// see game.Input.
+type input struct {
+	keys map[game.Key]ebiten.Key // Ebiten key of every game key looked up so far
+}
+
+// KeyPressDuration returns how many updates the key has been held down.
+// Keys with names that Ebiten doesn't know are never pressed.
+func (in *input) KeyPressDuration(key game.Key) int {
+	k, ok := in.keys[key]
+	if !ok {
+		if err := k.UnmarshalText([]byte(key)); err != nil {
+			log.Printf("Error looking up key: %v", err)
+			k = -1
+		}
+		if in.keys == nil {
+			in.keys = make(map[game.Key]ebiten.Key)
+		}
+		in.keys[key] = k
+	}
+	if k < 0 {
+		return 0
+	}
+	return inpututil.KeyPressDuration(k)
+}
+
+func (*input) MousePressDuration() int {
+	return inpututil.MouseButtonPressDuration(ebiten.MouseButtonLeft)
+}
+
+func (*input) CursorPosition() (x, y int) {
+	return ebiten.CursorPosition()
+}
+
+func (*input) JustPressedTouchIDs() []game.TouchID {
+	var ids []game.TouchID
+	for _, id := range inpututil.AppendJustPressedTouchIDs(nil) {
+		ids = append(ids, game.TouchID(id))
+	}
+	return ids
+}
+
+func (*input) IsTouchJustReleased(id game.TouchID) bool {
+	return inpututil.IsTouchJustReleased(ebiten.TouchID(id))
+}
+
+// TouchPosition returns the position of the finger. Ebiten no longer knows
+// the position of a finger once it is lifted, so its last position is used.
+func (*input) TouchPosition(id game.TouchID) (x, y int) {
+	if inpututil.IsTouchJustReleased(ebiten.TouchID(id)) {
+		return inpututil.TouchPositionInPreviousTick(ebiten.TouchID(id))
+	}
+	return ebiten.TouchPosition(ebiten.TouchID(id))
+}
+
+func (*input) JustConnectedGamepadIDs() []game.GamepadID {
+	var ids []game.GamepadID
+	for _, id := range inpututil.AppendJustConnectedGamepadIDs(nil) {
+		ids = append(ids, game.GamepadID(id))
+	}
+	return ids
+}
+
+func (*input) IsGamepadJustDisconnected(id game.GamepadID) bool {
+	return inpututil.IsGamepadJustDisconnected(ebiten.GamepadID(id))
+}
+
+func (*input) GamepadName(id game.GamepadID) string {
+	return ebiten.GamepadName(ebiten.GamepadID(id))
+}
+
+func (*input) IsStandardGamepad(id game.GamepadID) bool {
+	return ebiten.IsStandardGamepadLayoutAvailable(ebiten.GamepadID(id))
+}
+
+// GamepadButtonPressDuration returns how many updates the button has been
+// held down. Ebiten numbers the buttons of the standard layout like the game.
+func (*input) GamepadButtonPressDuration(id game.GamepadID, button game.GamepadButton) int {
+	return inpututil.StandardGamepadButtonPressDuration(ebiten.GamepadID(id), ebiten.StandardGamepadButton(button))
+}
+
+func (*input) LeftStick(id game.GamepadID) (horizontal, vertical float64) {
+	return ebiten.StandardGamepadAxisValue(ebiten.GamepadID(id), ebiten.StandardGamepadAxisLeftStickHorizontal),
+		ebiten.StandardGamepadAxisValue(ebiten.GamepadID(id), ebiten.StandardGamepadAxisLeftStickVertical)
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"image"
+	"image/color"
+	"zenmojo/layout"
+	"zenmojo/scoring"
+	"zenmojo/tiles"
+	"zenmojo/view"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.design/x/clipboard"
+)
+
+// viewScreen finds what view drew where in the last frame, see game.Screen.
+type viewScreen struct{}
+
+func (viewScreen) SettingsOptionAt(l layout.Layout, x, y, numOptions int) int {
+	return view.SettingsOptionAt(l, x, y, numOptions)
+}
+
+func (viewScreen) DistributionColorAt(l layout.Layout, breakdown map[color.Color]scoring.ColorBreakdown, shapes map[tiles.Cell]scoring.Shape, x, y int) color.Color {
+	return view.DistributionColorAt(l, breakdown, shapes, x, y)
+}
+
+func (viewScreen) IsShareCodeClicked(l layout.Layout, x, y int, code string) bool {
+	return view.IsShareCodeClicked(l, x, y, code)
+}
+
+// systemClipboard is the clipboard of the operating system. It has to be
+// initialized with clipboard.Init before it is used.
+type systemClipboard struct{}
+
+func (systemClipboard) Read() string {
+	return string(clipboard.Read(clipboard.FmtText))
+}
+
+func (systemClipboard) Write(text string) {
+	clipboard.Write(clipboard.FmtText, []byte(text))
+}
+
+// ebitenWindow is the window of Ebiten, see game.Window.
+type ebitenWindow struct{}
+
+func (ebitenWindow) IsFullscreen() bool {
+	return ebiten.IsFullscreen()
+}
+
+func (ebitenWindow) SetFullscreen(fullscreen bool) {
+	ebiten.SetFullscreen(fullscreen)
+}
+
+func (ebitenWindow) SetIcon(icons []image.Image) {
+	ebiten.SetWindowIcon(icons)
+}
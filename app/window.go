@@ -0,0 +1,71 @@
+package app
+
+import (
+	"log"
+	"zenmojo/window"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// RestoreWindow sizes and places the window as it was when the game was last
+// closed, see saveWindow, and makes it fullscreen if it was. It returns false
+// if there is nothing to restore, e.g. on the first run, so that the caller
+// can choose a default size.
+func RestoreWindow() bool {
+	path, err := window.DefaultPath()
+	if err != nil {
+		log.Printf("Error locating window geometry: %v", err)
+		return false
+	}
+	saved, ok, err := window.Load(path)
+	if err != nil {
+		log.Printf("Error loading window geometry: %v", err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	// The monitors may have changed since the window was saved
+	monitors := ebiten.AppendMonitors(nil)
+	screens := make([]window.Screen, len(monitors))
+	for i, m := range monitors {
+		w, h := m.Size()
+		screens[i] = window.Screen{Name: m.Name(), Width: w, Height: h}
+	}
+	geometry, screen, positioned, ok := saved.Restore(screens)
+	if !ok {
+		return false
+	}
+	ebiten.SetMonitor(monitors[screen])
+	ebiten.SetWindowSize(geometry.Width, geometry.Height)
+	if positioned {
+		ebiten.SetWindowPosition(geometry.X, geometry.Y)
+	}
+	ebiten.SetFullscreen(geometry.Fullscreen)
+	return true
+}
+
+// saveWindow remembers the size, position and fullscreen state of the window
+// for the next run. In fullscreen, the size and position of the window before
+// it became fullscreen are saved.
+func saveWindow() {
+	path, err := window.DefaultPath()
+	if err != nil {
+		log.Printf("Error locating window geometry: %v", err)
+		return
+	}
+	x, y := ebiten.WindowPosition()
+	w, h := ebiten.WindowSize()
+	geometry := window.Geometry{
+		Monitor:    ebiten.Monitor().Name(),
+		X:          x,
+		Y:          y,
+		Width:      w,
+		Height:     h,
+		Fullscreen: ebiten.IsFullscreen(),
+	}
+	if err := geometry.Save(path); err != nil {
+		log.Printf("Error saving window geometry: %v", err)
+	}
+}
@@ -44,7 +44,11 @@ func NewManager(moveSoundData []byte) *Manager {
 }
 
 // PlayMoveSound plays the sound for a piece move, adjusting its speed to the given duration.
+// A nil manager plays nothing, e.g. in tests without audio.
 func (m *Manager) PlayMoveSound(animationDuration float64) {
+	if m == nil {
+		return
+	}
 	// Decode the WAV data from memory to get a PCM stream.
 	decodedStream, err := wav.DecodeWithoutResampling(bytes.NewReader(m.moveSoundData))
 	if err != nil {
@@ -2,19 +2,117 @@ package board
 
 import (
 	"image/color"
+	"math"
+	"time"
+	"zenmojo/clock"
 	"zenmojo/config"
+	"zenmojo/easing"
+	"zenmojo/layout"
+	"zenmojo/tiles"
+)
+
+// SwapMode determines which pairs of tiles may be swapped and what a swap costs.
+type SwapMode int
 
-	"github.com/hajimehoshi/ebiten/v2"
+const (
+	// SwapAny allows any two tiles to be swapped for one move.
+	SwapAny SwapMode = iota
+	// SwapDistance allows any two tiles to be swapped, but a swap costs
+	// the Manhattan distance between them.
+	SwapDistance
+	// SwapAdjacent only allows swapping orthogonally adjacent tiles.
+	SwapAdjacent
+	// SwapSlide is the sliding-puzzle variant: the board has one empty cell
+	// and a move slides an adjacent tile into it.
+	SwapSlide
 )
 
+// String returns a human-readable name for the swap mode.
+func (m SwapMode) String() string {
+	switch m {
+	case SwapDistance:
+		return "Distance"
+	case SwapAdjacent:
+		return "Adjacent"
+	case SwapSlide:
+		return "Sliding"
+	default:
+		return "Free"
+	}
+}
+
+// IsValid reports whether m is one of the defined swap modes, e.g. for a mode
+// read from a share code.
+func (m SwapMode) IsValid() bool {
+	return m >= SwapAny && m <= SwapSlide
+}
+
+// Next returns the swap mode following m, wrapping around after the last one.
+func (m SwapMode) Next() SwapMode {
+	return (m + 1) % (SwapSlide + 1)
+}
+
+// Cost returns the cost of swapping the tiles at (x1, y1) and (x2, y2).
+// allowed is false if the mode does not permit the swap at all.
+func (m SwapMode) Cost(x1, y1, x2, y2 int) (cost int, allowed bool) {
+	distance := abs(x1-x2) + abs(y1-y2)
+	if distance == 0 {
+		return 0, false
+	}
+	switch m {
+	case SwapDistance:
+		return distance, true
+	case SwapAdjacent, SwapSlide:
+		return 1, distance == 1
+	default:
+		return 1, true
+	}
+}
+
+// PathCost returns the cost of exchanging the tiles at (x1, y1) and (x2, y2) using
+// only moves the mode allows. Unlike Cost it is defined for every pair of tiles,
+// which makes it the metric for estimating how many moves a board needs.
+func (m SwapMode) PathCost(x1, y1, x2, y2 int) int {
+	distance := abs(x1-x2) + abs(y1-y2)
+	if distance == 0 {
+		return 0
+	}
+	switch m {
+	case SwapDistance:
+		return distance
+	case SwapAdjacent:
+		// One tile is passed along to the other, which then travels back one step less.
+		return 2*distance - 1
+	case SwapSlide:
+		// A rough estimate: the gap has to be walked around a tile for every step it takes.
+		return 3 * distance
+	default:
+		return 1
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
 // Board represents the game board and its state.
 type Board struct {
-	grid              [][]color.Color
+	cells             tiles.Grid
+	palette           []color.Color // Colors of the cells, starting with config.Palette
+	swapMode          SwapMode
+	moveCost          int
+	maxMoveCost       int
 	selectedX         int
 	selectedY         int
 	IsAnimating       bool
 	AnimationProgress float64
 	animationDuration float64
+	animationStart    time.Time
+	easing            easing.Easing
+	clock             clock.Clock
 	animatingPiece1X  int
 	animatingPiece1Y  int
 	animatingPiece2X  int
@@ -23,10 +121,7 @@ type Board struct {
 
 // New creates a new, initialized game board with a random, valid layout.
 func New() *Board {
-	b := &Board{
-		selectedX: -1,
-		selectedY: -1,
-	}
+	b := newBoard(tiles.Grid{}, config.Palette)
 
 	totalTiles := config.GridSize * config.GridSize
 
@@ -38,23 +133,105 @@ func New() *Board {
 	colors := assignColorsToGroups(groupSizes)
 
 	// 3. Create the final grid from the color array
-	b.grid = createColorGrid(colors)
+	b.cells = createColorGrid(colors)
 
 	return b
 }
 
-// NewFromGrid creates a new board from a pre-existing grid.
+// NewSliding creates a new board for the sliding-puzzle variant. All cells
+// but one are filled with tiles; the remaining cell is empty.
+func NewSliding() *Board {
+	b := newBoard(tiles.Grid{}, config.Palette)
+	b.swapMode = SwapSlide
+
+	// One cell stays empty, so the color groups are generated for one tile less.
+	totalTiles := config.GridSize*config.GridSize - 1
+	groupSizes := generateGroupSizes(totalTiles)
+	colors := assignColorsToGroups(groupSizes)
+	b.cells = createSlidingGrid(colors)
+
+	return b
+}
+
+// NewFromGrid creates a new board from a pre-existing grid. Colors that are
+// not in config.Palette are added to the board's palette.
 func NewFromGrid(grid [][]color.Color) *Board {
+	cells, palette := tiles.FromColors(grid, config.Palette)
+	return newBoard(cells, palette)
+}
+
+// NewFromCells creates a new board from a pre-existing grid of cells, whose
+// palette is config.Palette. The board works on a copy of the cells.
+func NewFromCells(cells tiles.Grid) *Board {
+	return newBoard(cells.Clone(), config.Palette)
+}
+
+// newBoard creates a board with the given cells and palette, no selection,
+// the configured swap easing and the system clock. All constructors build
+// their boards through it.
+func newBoard(cells tiles.Grid, palette []color.Color) *Board {
 	return &Board{
-		grid:      grid,
+		cells:     cells,
+		palette:   palette,
 		selectedX: -1,
 		selectedY: -1,
+		easing:    config.SwapEasing,
+		clock:     clock.System{},
 	}
 }
 
-// Grid returns the current grid state for drawing.
+// Grid returns a copy of the current grid state as colors, indexed by row
+// and column. It builds a new copy on every call, so it is only meant as an
+// adapter for drawing and for APIs that work with colors. Scoring works on
+// Cells and Palette instead.
 func (b *Board) Grid() [][]color.Color {
-	return b.grid
+	return b.cells.Colors(b.palette)
+}
+
+// Cells returns the current grid state. It must not be modified.
+func (b *Board) Cells() tiles.Grid {
+	return b.cells
+}
+
+// Palette returns the colors of the board's cells, see tiles.Cell.Color.
+func (b *Board) Palette() []color.Color {
+	return b.palette
+}
+
+// SetClock sets the clock that times the board's animations.
+func (b *Board) SetClock(c clock.Clock) {
+	b.clock = c
+}
+
+// Easing returns the easing of the board's swap animations.
+func (b *Board) Easing() easing.Easing {
+	return b.easing
+}
+
+// SetEasing sets the easing of the board's swap animations.
+func (b *Board) SetEasing(e easing.Easing) {
+	b.easing = e
+}
+
+// SwapMode returns the swap mode the board enforces.
+func (b *Board) SwapMode() SwapMode {
+	return b.swapMode
+}
+
+// SetSwapMode changes the swap mode the board enforces for subsequent moves.
+func (b *Board) SetSwapMode(mode SwapMode) {
+	b.swapMode = mode
+}
+
+// SetMaxMoveCost limits the cost of a single move, e.g. to the remaining move
+// budget of a challenge. Swaps that cost more are rejected. Zero means no limit.
+func (b *Board) SetMaxMoveCost(maxCost int) {
+	b.maxMoveCost = maxCost
+}
+
+// MoveCost returns the cost of the most recent move under the board's swap mode.
+func (b *Board) MoveCost() int {
+	return b.moveCost
 }
 
 // Selected returns the coordinates of the selected cell.
@@ -67,54 +244,179 @@ func (b *Board) AnimatingPieces() (int, int, int, int) {
 	return b.animatingPiece1X, b.animatingPiece1Y, b.animatingPiece2X, b.animatingPiece2Y
 }
 
+// EasedProgress returns the progress of the current animation with the
+// board's easing applied. It may leave [0, 1] for easings that overshoot.
+func (b *Board) EasedProgress() float64 {
+	return b.easing.Apply(b.AnimationProgress)
+}
+
 // AnimationDuration returns the duration of the current animation in seconds.
 func (b *Board) AnimationDuration() float64 {
 	return b.animationDuration
 }
 
-// HandleInput processes a mouse click at the given screen coordinates.
-// It returns true if a move was made (a swap occurred).
-func (b *Board) HandleInput(mouseX, mouseY int) (moveMade bool) {
+// HandleInput processes a mouse click at the given screen coordinates in the
+// layout l. It returns true if a move was made (a swap occurred). See SelectCell.
+func (b *Board) HandleInput(l layout.Layout, mouseX, mouseY int) (moveMade bool) {
+	i, j, ok := CellAt(l, mouseX, mouseY)
+	if !ok {
+		return false
+	}
+	return b.SelectCell(i, j)
+}
+
+// SelectCell is the action of picking the cell at grid position (i, j), by
+// clicking it or by pressing a key on it. With a selected tile, the two tiles
+// are swapped; in the sliding-puzzle variant, the tile slides into the empty
+// cell. Otherwise the tile becomes the selection, or is deselected if it is
+// already selected. It returns true if a move was made (a swap occurred).
+// Swaps that the swap mode does not allow are rejected and the tile becomes
+// the new selection instead.
+func (b *Board) SelectCell(i, j int) (moveMade bool) {
 	if b.IsAnimating {
 		return false
 	}
-	for i := 0; i < config.GridSize; i++ {
-		for j := 0; j < config.GridSize; j++ {
-			x := config.GridOriginX + i*(config.SquareSize+config.Gap)
-			y := config.GridOriginY + j*(config.SquareSize+config.Gap)
-
-			if mouseX >= x && mouseX < x+config.SquareSize && mouseY >= y && mouseY < y+config.SquareSize {
-				if b.selectedX == -1 {
-					// Select a square
-					b.selectedX = i
-					b.selectedY = j
-				} else if b.selectedX == i && b.selectedY == j {
-					// Deselect if clicking the same square
-					b.selectedX = -1
-					b.selectedY = -1
-				} else {
-					// Start animation
-					b.IsAnimating = true
-					b.AnimationProgress = 0
-					b.animatingPiece1X = b.selectedX
-					b.animatingPiece1Y = b.selectedY
-					b.animatingPiece2X = i
-					b.animatingPiece2Y = j
-
-					// Calculate duration
-					b.animationDuration = config.SwapAnimationDuration * config.StretchFactor
-
-					b.selectedX = -1
-					b.selectedY = -1
-					return true // Move was initiated
-				}
-				return false
-			}
-		}
+	if x1, y1, x2, y2, ok := b.SwapWith(i, j); ok {
+		return b.Swap(x1, y1, x2, y2)
+	}
+	if b.swapMode == SwapSlide {
+		return false // Tiles are not selected in the sliding-puzzle variant
+	}
+
+	if b.selectedX == i && b.selectedY == j {
+		// Deselect if picking the same square
+		b.CancelSelection()
+	} else {
+		// Select the square, or move the selection to it if the swap is not allowed
+		b.selectedX = i
+		b.selectedY = j
 	}
 	return false
 }
 
+// CancelSelection is the action of deselecting the selected tile, if any.
+func (b *Board) CancelSelection() {
+	b.selectedX = -1
+	b.selectedY = -1
+}
+
+// Swap is the action of swapping the tiles at (x1, y1) and (x2, y2) directly.
+// It returns false and leaves the board unchanged if the swap mode or the move
+// budget does not allow the swap.
+func (b *Board) Swap(x1, y1, x2, y2 int) (moveMade bool) {
+	if !b.CanSwap(x1, y1, x2, y2) {
+		return false
+	}
+	cost, _ := b.swapMode.Cost(x1, y1, x2, y2)
+	b.startSwap(x1, y1, x2, y2, cost)
+	return true
+}
+
+// SwapAt returns the swap that a click at the given screen coordinates in the
+// layout l would make, without making it. See SwapWith.
+func (b *Board) SwapAt(l layout.Layout, mouseX, mouseY int) (x1, y1, x2, y2 int, ok bool) {
+	i, j, onCell := CellAt(l, mouseX, mouseY)
+	if !onCell {
+		return 0, 0, 0, 0, false
+	}
+	return b.SwapWith(i, j)
+}
+
+// SwapWith returns the swap that selecting the cell at grid position (i, j)
+// would make, without making it. With a selected tile, that is a swap of the
+// selected and the picked tile; in the sliding-puzzle variant, it is a slide
+// of the picked tile into the empty cell. ok is false if selecting the cell
+// would not swap any tiles, e.g. because the swap mode or the move budget does
+// not allow it.
+func (b *Board) SwapWith(i, j int) (x1, y1, x2, y2 int, ok bool) {
+	if b.IsAnimating || !b.contains(i, j) {
+		return 0, 0, 0, 0, false
+	}
+
+	if b.swapMode == SwapSlide {
+		emptyX, emptyY, hasEmpty := b.EmptyCell()
+		if b.cells.At(j, i) == tiles.Empty || !hasEmpty {
+			return 0, 0, 0, 0, false
+		}
+		x1, y1, x2, y2 = i, j, emptyX, emptyY
+	} else {
+		if b.selectedX == -1 || (b.selectedX == i && b.selectedY == j) {
+			return 0, 0, 0, 0, false
+		}
+		x1, y1, x2, y2 = b.selectedX, b.selectedY, i, j
+	}
+
+	if !b.allows(x1, y1, x2, y2) {
+		return 0, 0, 0, 0, false
+	}
+	return x1, y1, x2, y2, true
+}
+
+// contains reports whether (i, j) is a cell of the board.
+func (b *Board) contains(i, j int) bool {
+	return i >= 0 && i < b.cells.Width && j >= 0 && j < b.cells.Height
+}
+
+// CanSwap reports whether the tiles at (x1, y1) and (x2, y2) may be swapped
+// right now, see Swap.
+func (b *Board) CanSwap(x1, y1, x2, y2 int) bool {
+	return !b.IsAnimating && b.contains(x1, y1) && b.contains(x2, y2) && b.allows(x1, y1, x2, y2)
+}
+
+// allows reports whether the swap mode and the move budget permit swapping
+// the tiles at (x1, y1) and (x2, y2). In the sliding-puzzle variant, one of
+// the cells has to be the empty one.
+func (b *Board) allows(x1, y1, x2, y2 int) bool {
+	if b.swapMode == SwapSlide && b.cells.At(y1, x1) != tiles.Empty && b.cells.At(y2, x2) != tiles.Empty {
+		return false
+	}
+	cost, allowed := b.swapMode.Cost(x1, y1, x2, y2)
+	return allowed && (b.maxMoveCost == 0 || cost <= b.maxMoveCost)
+}
+
+// startSwap starts the animation that swaps the pieces at (x1, y1) and (x2, y2).
+// The grid itself is only updated once the animation has finished.
+func (b *Board) startSwap(x1, y1, x2, y2, cost int) {
+	b.moveCost = cost
+	b.IsAnimating = true
+	b.AnimationProgress = 0
+	b.animationStart = b.clock.Now()
+	b.animatingPiece1X = x1
+	b.animatingPiece1Y = y1
+	b.animatingPiece2X = x2
+	b.animatingPiece2Y = y2
+
+	b.animationDuration = SwapDuration(x1, y1, x2, y2)
+
+	b.CancelSelection()
+}
+
+// SwapDuration returns the duration in seconds of the animation that swaps the
+// tiles at (x1, y1) and (x2, y2). Tiles that travel further take longer, so
+// that long swaps do not look hasty, up to config.SwapAnimationMax.
+func SwapDuration(x1, y1, x2, y2 int) float64 {
+	distance := math.Hypot(float64(x1-x2), float64(y1-y2))
+	duration := config.SwapAnimationDuration + config.SwapAnimationPerCell*math.Max(distance-1, 0)
+	return math.Min(duration, config.SwapAnimationMax) * config.StretchFactor
+}
+
+// CellAt returns the grid cell under the given screen coordinates in the
+// layout l. ok is false if the coordinates are not on a cell (e.g. in a gap).
+func CellAt(l layout.Layout, mouseX, mouseY int) (i, j int, ok bool) {
+	return l.CellAt(mouseX, mouseY)
+}
+
+// EmptyCell returns the coordinates of the first empty cell on the board.
+// ok is false if the board is full.
+func (b *Board) EmptyCell() (x, y int, ok bool) {
+	for k, cell := range b.cells.Cells {
+		if cell == tiles.Empty {
+			return k % b.cells.Width, k / b.cells.Width, true
+		}
+	}
+	return -1, -1, false
+}
+
 // UpdateAnimation progresses the piece swapping animation.
 // It returns true when the animation is finished.
 func (b *Board) UpdateAnimation() (animationFinished bool) {
@@ -122,10 +424,11 @@ func (b *Board) UpdateAnimation() (animationFinished bool) {
 		return false
 	}
 
-	// Update progress based on time
+	// Update progress based on the time since the animation started, so that
+	// it plays at the same speed however often it is updated.
 	if b.animationDuration > 0 {
-		elapsed := 1.0 / float64(ebiten.TPS())
-		b.AnimationProgress += elapsed / b.animationDuration
+		elapsed := b.clock.Now().Sub(b.animationStart).Seconds()
+		b.AnimationProgress = elapsed / b.animationDuration
 	} else {
 		b.AnimationProgress = 1.0
 	}
@@ -133,7 +436,7 @@ func (b *Board) UpdateAnimation() (animationFinished bool) {
 	if b.AnimationProgress >= 1.0 {
 		b.AnimationProgress = 1.0
 		// Swap pieces in the grid
-		b.grid[b.animatingPiece1Y][b.animatingPiece1X], b.grid[b.animatingPiece2Y][b.animatingPiece2X] = b.grid[b.animatingPiece2Y][b.animatingPiece2X], b.grid[b.animatingPiece1Y][b.animatingPiece1X]
+		b.cells.Swap(b.animatingPiece1Y, b.animatingPiece1X, b.animatingPiece2Y, b.animatingPiece2X)
 		b.IsAnimating = false
 		return true // Animation finished
 	}
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"testing/quick"
 	"zenmojo/sharing"
+	"zenmojo/tiles"
 )
 
 // TestBoardPropertiesQuick uses testing/quick to verify board properties
@@ -11,13 +12,13 @@ func TestBoardPropertiesQuick(t *testing.T) {
 	// Define a function that generates a board and checks its properties
 	f := func() bool {
 		board := New()
-		props := checkBoardProperties(board.Grid())
+		props := checkBoardProperties(board.Cells())
 
 		// If any property is false, log it for debugging
 		if !props.HasNoSingleStones || !props.HasValidGroupSizes ||
 			!props.UsesValidColors || !props.IsFull {
 			// Get the share code for the failing board
-			shareCode, err := sharing.Encode(board.Grid())
+			shareCode, err := sharing.EncodeTiles(board.Cells())
 			if err != nil {
 				t.Logf("Error generating share code: %v", err)
 			}
@@ -38,3 +39,83 @@ func TestBoardPropertiesQuick(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+// TestSlidingBoardPropertiesQuick verifies the properties of generated sliding-puzzle boards
+func TestSlidingBoardPropertiesQuick(t *testing.T) {
+	f := func() bool {
+		board := NewSliding()
+		props := checkSlidingBoardProperties(board.Cells())
+
+		if !props.HasNoSingleStones || !props.HasValidGroupSizes ||
+			!props.UsesValidColors || !props.HasOneEmptyCell || !props.IsSolvable {
+			shareCode, err := sharing.EncodeTiles(board.Cells())
+			if err != nil {
+				t.Logf("Error generating share code: %v", err)
+			}
+
+			t.Logf("Found invalid sliding board with properties: %+v\nBoard share code: %s", props, shareCode)
+			return false
+		}
+		return true
+	}
+
+	config := &quick.Config{
+		MaxCount: 200,
+	}
+
+	if err := quick.Check(f, config); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestShapeBoardPropertiesQuick verifies that target-shape boards are valid boards
+// and that every color has exactly as many tiles as its required shape.
+func TestShapeBoardPropertiesQuick(t *testing.T) {
+	f := func(sliding bool) bool {
+		board, shapes := NewWithShapes(sliding)
+		counts := board.Cells().Counts()
+
+		validBoard := true
+		if sliding {
+			props := checkSlidingBoardProperties(board.Cells())
+			validBoard = props.HasNoSingleStones && props.HasValidGroupSizes &&
+				props.UsesValidColors && props.HasOneEmptyCell && props.IsSolvable
+		} else {
+			props := checkBoardProperties(board.Cells())
+			validBoard = props.HasNoSingleStones && props.HasValidGroupSizes &&
+				props.UsesValidColors && props.IsFull
+		}
+
+		matchesShapes := true
+		colors := 0
+		for cell, n := range counts {
+			if n == 0 {
+				continue
+			}
+			colors++
+			if shape, ok := shapes[tiles.Cell(cell)]; !ok || shape.Size() != n {
+				matchesShapes = false
+			}
+		}
+		matchesShapes = matchesShapes && len(shapes) == colors
+
+		if !validBoard || !matchesShapes {
+			shareCode, err := sharing.EncodeTiles(board.Cells())
+			if err != nil {
+				t.Logf("Error generating share code: %v", err)
+			}
+
+			t.Logf("Found invalid target-shape board (valid: %v, matches shapes: %v)\nBoard share code: %s", validBoard, matchesShapes, shareCode)
+			return false
+		}
+		return true
+	}
+
+	config := &quick.Config{
+		MaxCount: 200,
+	}
+
+	if err := quick.Check(f, config); err != nil {
+		t.Error(err)
+	}
+}
@@ -3,8 +3,12 @@ package board
 import (
 	"image/color"
 	"testing"
+	"time"
+	"zenmojo/clock"
 	"zenmojo/config"
+	"zenmojo/layout"
 	"zenmojo/sharing"
+	"zenmojo/tiles"
 )
 
 func TestNew(t *testing.T) {
@@ -77,7 +81,7 @@ func TestNew(t *testing.T) {
 			for c := range usedColors {
 				found := false
 				for _, paletteColor := range config.Palette {
-					if colorEqual(c, paletteColor) {
+					if c == paletteColor {
 						found = true
 						break
 					}
@@ -103,9 +107,283 @@ func TestBoardEncodeDecode(t *testing.T) {
 	// Compare the grids
 	for i := 0; i < config.GridSize; i++ {
 		for j := 0; j < config.GridSize; j++ {
-			if !colorEqual(originalGrid[i][j], copiedGrid[i][j]) {
+			if originalGrid[i][j] != copiedGrid[i][j] {
 				t.Errorf("Grid mismatch at position (%d, %d)", i, j)
 			}
 		}
 	}
 }
+
+func TestSwapModeCost(t *testing.T) {
+	testCases := []struct {
+		name            string
+		mode            SwapMode
+		x1, y1, x2, y2  int
+		expectedCost    int
+		expectedAllowed bool
+	}{
+		{"free swap far apart", SwapAny, 0, 0, 9, 9, 1, true},
+		{"free swap of the same tile", SwapAny, 3, 3, 3, 3, 0, false},
+		{"distance swap costs manhattan distance", SwapDistance, 1, 2, 4, 6, 7, true},
+		{"distance swap of neighbors", SwapDistance, 5, 5, 5, 6, 1, true},
+		{"adjacent swap of neighbors", SwapAdjacent, 2, 2, 3, 2, 1, true},
+		{"adjacent swap rejects diagonals", SwapAdjacent, 2, 2, 3, 3, 1, false},
+		{"adjacent swap rejects distant tiles", SwapAdjacent, 0, 0, 0, 2, 1, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cost, allowed := tc.mode.Cost(tc.x1, tc.y1, tc.x2, tc.y2)
+			if allowed != tc.expectedAllowed {
+				t.Fatalf("Expected allowed=%v, got %v", tc.expectedAllowed, allowed)
+			}
+			if allowed && cost != tc.expectedCost {
+				t.Errorf("Expected cost %d, got %d", tc.expectedCost, cost)
+			}
+		})
+	}
+}
+
+// cellCenter returns a layout of the smallest portrait canvas and the screen
+// coordinates of the center of cell (i, j) in it, for HandleInput and SwapAt.
+func cellCenter(i, j int) (l layout.Layout, x, y int) {
+	l = layout.New(config.ScreenWidth, config.ScreenHeight, false)
+	x, y = l.Cell(i, j)
+	return l, x + l.Square/2, y + l.Square/2
+}
+
+func TestHandleInputRespectsSwapMode(t *testing.T) {
+	b := New()
+	b.SetSwapMode(SwapAdjacent)
+
+	b.HandleInput(cellCenter(2, 2))
+	if moved := b.HandleInput(cellCenter(4, 2)); moved {
+		t.Fatal("Expected a distant swap to be rejected in adjacent mode")
+	}
+	if x, y := b.Selected(); x != 4 || y != 2 {
+		t.Errorf("Expected the rejected target to become the selection, got (%d, %d)", x, y)
+	}
+	if moved := b.HandleInput(cellCenter(4, 3)); !moved {
+		t.Fatal("Expected an adjacent swap to be accepted")
+	}
+	if b.MoveCost() != 1 {
+		t.Errorf("Expected move cost 1, got %d", b.MoveCost())
+	}
+
+	b = New()
+	b.SetSwapMode(SwapDistance)
+	b.HandleInput(cellCenter(0, 0))
+	if moved := b.HandleInput(cellCenter(3, 4)); !moved {
+		t.Fatal("Expected a distance swap to be accepted")
+	}
+	if b.MoveCost() != 7 {
+		t.Errorf("Expected move cost 7, got %d", b.MoveCost())
+	}
+}
+
+func TestIsSlidingSolvable(t *testing.T) {
+	a, b, c, empty := tiles.CellOf(0), tiles.CellOf(1), tiles.CellOf(2), tiles.Empty
+
+	// grid builds a 2x2 grid from its cells in row-major order.
+	grid := func(cells ...tiles.Cell) tiles.Grid {
+		g := tiles.New(2, 2)
+		copy(g.Cells, cells)
+		return g
+	}
+	target := grid(a, b, c, empty)
+
+	testCases := []struct {
+		name     string
+		start    tiles.Grid
+		expected bool
+	}{
+		{"already solved", grid(a, b, c, empty), true},
+		{"one slide away", grid(a, b, empty, c), true},
+		{"three tiles rotated", grid(b, c, a, empty), true},
+		{"two tiles swapped", grid(b, a, c, empty), false},
+		{"different tiles", grid(a, a, c, empty), false},
+		{"no empty cell", grid(a, b, c, a), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := IsSlidingSolvable(tc.start, target); actual != tc.expected {
+				t.Errorf("Expected solvable=%v, got %v", tc.expected, actual)
+			}
+		})
+	}
+
+	// With a duplicate color the parity can always be fixed.
+	if !IsSlidingSolvable(grid(b, a, a, empty), grid(a, a, b, empty)) {
+		t.Error("Expected a board with duplicate colors to be solvable")
+	}
+	if CanSolveSliding(grid(a, empty, b, c)) {
+		t.Error("Expected distinct tiles out of parity not to be solvable")
+	}
+}
+
+func TestHandleInputSlides(t *testing.T) {
+	b := NewSliding()
+	fake := clock.NewFake()
+	b.SetClock(fake)
+	emptyX, emptyY, ok := b.EmptyCell()
+	if !ok {
+		t.Fatal("Expected a sliding board to have an empty cell")
+	}
+
+	// A tile that is not next to the empty cell cannot slide.
+	if moved := b.HandleInput(cellCenter(0, 0)); moved {
+		t.Fatal("Expected a distant tile not to slide")
+	}
+
+	// The tile to the left of the empty cell slides into it.
+	tile := b.Grid()[emptyY][emptyX-1]
+	if moved := b.HandleInput(cellCenter(emptyX-1, emptyY)); !moved {
+		t.Fatal("Expected the neighboring tile to slide")
+	}
+	if b.UpdateAnimation() {
+		t.Fatal("Expected the animation not to finish before any time has passed")
+	}
+	fake.Advance(time.Duration(b.AnimationDuration()*float64(time.Second)) + time.Millisecond)
+	if !b.UpdateAnimation() || b.IsAnimating {
+		t.Fatal("Expected the animation to finish once its duration has passed")
+	}
+	if b.Grid()[emptyY][emptyX] != tile || b.Grid()[emptyY][emptyX-1] != nil {
+		t.Error("Expected the tile and the empty cell to trade places")
+	}
+}
+
+func TestSwapModePathCost(t *testing.T) {
+	testCases := []struct {
+		mode     SwapMode
+		expected int
+	}{
+		{SwapAny, 1},
+		{SwapDistance, 5},
+		{SwapAdjacent, 9},
+		{SwapSlide, 15},
+	}
+
+	for _, tc := range testCases {
+		if cost := tc.mode.PathCost(1, 1, 3, 4); cost != tc.expected {
+			t.Errorf("%s: expected path cost %d, got %d", tc.mode, tc.expected, cost)
+		}
+		if cost := tc.mode.PathCost(2, 2, 2, 2); cost != 0 {
+			t.Errorf("%s: expected no cost for the same tile, got %d", tc.mode, cost)
+		}
+	}
+}
+
+func TestSwapAtDoesNotMove(t *testing.T) {
+	b := New()
+	b.SetSwapMode(SwapAdjacent)
+	if _, _, _, _, ok := b.SwapAt(cellCenter(2, 3)); ok {
+		t.Error("Expected no swap without a selected tile")
+	}
+
+	b.HandleInput(cellCenter(2, 2))
+	if _, _, _, _, ok := b.SwapAt(cellCenter(4, 2)); ok {
+		t.Error("Expected no swap with a distant tile in adjacent mode")
+	}
+	x1, y1, x2, y2, ok := b.SwapAt(cellCenter(2, 3))
+	if !ok || x1 != 2 || y1 != 2 || x2 != 2 || y2 != 3 {
+		t.Errorf("Expected a swap of (2, 2) and (2, 3), got (%d, %d) and (%d, %d), ok %v", x1, y1, x2, y2, ok)
+	}
+	if x, y := b.Selected(); x != 2 || y != 2 || b.IsAnimating {
+		t.Error("Expected SwapAt to leave the board unchanged")
+	}
+}
+
+func TestSwapDuration(t *testing.T) {
+	adjacent := SwapDuration(0, 0, 1, 0)
+	if adjacent != config.SwapAnimationDuration*config.StretchFactor {
+		t.Errorf("Expected adjacent tiles to swap in the base duration, got %v", adjacent)
+	}
+	if d := SwapDuration(3, 4, 3, 3); d != adjacent {
+		t.Errorf("Expected vertical and horizontal neighbors to swap equally fast, got %v and %v", d, adjacent)
+	}
+	if d := SwapDuration(0, 0, 3, 0); d <= adjacent {
+		t.Errorf("Expected a longer swap to take longer than %v, got %v", adjacent, d)
+	}
+	if d := SwapDuration(0, 0, config.GridSize-1, config.GridSize-1); d != config.SwapAnimationMax*config.StretchFactor {
+		t.Errorf("Expected the longest swap to be capped at %v, got %v", config.SwapAnimationMax, d)
+	}
+
+	b := New()
+	b.startSwap(1, 1, 5, 1, 1)
+	if b.AnimationDuration() != SwapDuration(1, 1, 5, 1) {
+		t.Errorf("Expected the animation to last %v, got %v", SwapDuration(1, 1, 5, 1), b.AnimationDuration())
+	}
+}
+
+func TestCellActions(t *testing.T) {
+	b := New()
+	b.SetSwapMode(SwapAdjacent)
+
+	if moved := b.SelectCell(2, 2); moved {
+		t.Fatal("Expected selecting a tile not to move")
+	}
+	b.CancelSelection()
+	if x, y := b.Selected(); x != -1 || y != -1 {
+		t.Errorf("Expected no selection after cancelling, got (%d, %d)", x, y)
+	}
+	if _, _, _, _, ok := b.SwapWith(2, 3); ok {
+		t.Error("Expected no swap after the selection was cancelled")
+	}
+
+	if b.Swap(2, 2, 4, 2) || b.Swap(2, 2, 2, config.GridSize) {
+		t.Error("Expected swaps that the mode does not allow or that leave the board to be rejected")
+	}
+	if b.IsAnimating {
+		t.Fatal("Expected rejected swaps to leave the board unchanged")
+	}
+	if !b.Swap(2, 2, 2, 3) || !b.IsAnimating {
+		t.Fatal("Expected an adjacent swap to be made directly")
+	}
+	if x1, y1, x2, y2 := b.AnimatingPieces(); x1 != 2 || y1 != 2 || x2 != 2 || y2 != 3 {
+		t.Errorf("Expected (2, 2) and (2, 3) to be swapped, got (%d, %d) and (%d, %d)", x1, y1, x2, y2)
+	}
+}
+
+func TestNewFromCellsSwaps(t *testing.T) {
+	b := NewFromCells(New().Cells())
+	if b.Easing() != config.SwapEasing {
+		t.Error("Expected a board from cells to use the configured swap easing")
+	}
+
+	if moved := b.SelectCell(2, 2); moved {
+		t.Fatal("Expected selecting a tile not to move")
+	}
+	if moved := b.SelectCell(4, 5); !moved || !b.IsAnimating {
+		t.Fatal("Expected selecting a second tile to start a swap")
+	}
+	if b.UpdateAnimation() {
+		t.Error("Expected the swap not to finish right away")
+	}
+
+	b = NewFromCells(New().Cells())
+	if !b.Swap(0, 0, 1, 0) || !b.IsAnimating {
+		t.Error("Expected a direct swap to animate")
+	}
+}
+
+func TestSwapSlidesOnlyIntoEmptyCell(t *testing.T) {
+	b := NewSliding()
+	emptyX, emptyY, _ := b.EmptyCell()
+	// A neighbor of the empty cell, and a neighbor of that tile that is not the empty cell
+	tileX := emptyX - 1
+	if tileX < 0 {
+		tileX = emptyX + 1
+	}
+	otherY := emptyY - 1
+	if otherY < 0 {
+		otherY = emptyY + 1
+	}
+
+	if b.CanSwap(tileX, emptyY, tileX, otherY) {
+		t.Error("Expected two tiles not to be swapped in the sliding-puzzle variant")
+	}
+	if !b.Swap(tileX, emptyY, emptyX, emptyY) {
+		t.Error("Expected a tile to slide into the neighboring empty cell")
+	}
+}
@@ -1,8 +1,8 @@
 package board
 
 import (
-	"image/color"
 	"zenmojo/config"
+	"zenmojo/tiles"
 )
 
 // BoardProperties defines the properties that any valid board must satisfy
@@ -13,21 +13,8 @@ type BoardProperties struct {
 	IsFull             bool
 }
 
-// Helper function to compare colors (since color.Color doesn't implement equality)
-func colorEqual(c1, c2 color.Color) bool {
-	if c1 == nil && c2 == nil {
-		return true
-	}
-	if c1 == nil || c2 == nil {
-		return false
-	}
-	r1, g1, b1, a1 := c1.RGBA()
-	r2, g2, b2, a2 := c2.RGBA()
-	return r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2
-}
-
 // checkBoardProperties verifies all required properties of a game board
-func checkBoardProperties(grid [][]color.Color) BoardProperties {
+func checkBoardProperties(cells tiles.Grid) BoardProperties {
 	// Initialize properties as true (assume all checks will pass)
 	props := BoardProperties{
 		HasNoSingleStones:  true,
@@ -36,33 +23,22 @@ func checkBoardProperties(grid [][]color.Color) BoardProperties {
 		IsFull:             true,
 	}
 
-	// Count stones by color
-	colorCounts := make(map[color.Color]int)
-	for i := 0; i < len(grid); i++ {
-		for j := 0; j < len(grid[i]); j++ {
-			c := grid[i][j]
-			if c == nil {
-				props.IsFull = false
-				continue
-			}
-			colorCounts[c]++
-
-			// Check if color is from valid palette
-			validColor := false
-			for _, paletteColor := range config.Palette {
-				if colorEqual(c, paletteColor) {
-					validColor = true
-					break
-				}
-			}
-			if !validColor {
-				props.UsesValidColors = false
-			}
+	for _, cell := range cells.Cells {
+		if cell == tiles.Empty {
+			props.IsFull = false
+			continue
+		}
+		// Check if color is from valid palette
+		if cell.PaletteIndex() >= len(config.Palette) {
+			props.UsesValidColors = false
 		}
 	}
 
 	// Check group sizes
-	for _, count := range colorCounts {
+	for _, count := range cells.Counts() {
+		if count == 0 {
+			continue // Color not on the board
+		}
 		if count == 1 {
 			props.HasNoSingleStones = false
 		}
@@ -73,3 +49,25 @@ func checkBoardProperties(grid [][]color.Color) BoardProperties {
 
 	return props
 }
+
+// SlidingBoardProperties defines the properties that any valid sliding-puzzle board must satisfy
+type SlidingBoardProperties struct {
+	HasNoSingleStones  bool
+	HasValidGroupSizes bool
+	UsesValidColors    bool
+	HasOneEmptyCell    bool
+	IsSolvable         bool
+}
+
+// checkSlidingBoardProperties verifies all required properties of a sliding-puzzle board.
+// The board is solvable if its canonical target arrangement can be reached by sliding moves.
+func checkSlidingBoardProperties(cells tiles.Grid) SlidingBoardProperties {
+	props := checkBoardProperties(cells)
+	return SlidingBoardProperties{
+		HasNoSingleStones:  props.HasNoSingleStones,
+		HasValidGroupSizes: props.HasValidGroupSizes,
+		UsesValidColors:    props.UsesValidColors,
+		HasOneEmptyCell:    cells.Count(tiles.Empty) == 1,
+		IsSolvable:         CanSolveSliding(cells),
+	}
+}
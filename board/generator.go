@@ -1,9 +1,9 @@
 package board
 
 import (
-	"image/color"
 	"math/rand"
 	"zenmojo/config"
+	"zenmojo/tiles"
 )
 
 // Target distribution for group sizes
@@ -171,12 +171,15 @@ func generateGroupSizes(totalTiles int) []int {
 
 // assignColorsToGroups takes the group sizes and assigns colors to each group,
 // ensuring that each color is used at most once until we run out of colors.
-func assignColorsToGroups(groupSizes []int) []color.Color {
-	var colors []color.Color
+// Colors are given as cells of config.Palette.
+func assignColorsToGroups(groupSizes []int) []tiles.Cell {
+	var colors []tiles.Cell
 
-	// Create a shuffled copy of the palette
-	availableColors := make([]color.Color, len(config.Palette))
-	copy(availableColors, config.Palette)
+	// Create a shuffled list of the palette colors
+	availableColors := make([]tiles.Cell, len(config.Palette))
+	for i := range availableColors {
+		availableColors[i] = tiles.CellOf(i)
+	}
 	rand.Shuffle(len(availableColors), func(i, j int) {
 		availableColors[i], availableColors[j] = availableColors[j], availableColors[i]
 	})
@@ -203,14 +206,126 @@ func assignColorsToGroups(groupSizes []int) []color.Color {
 	return colors
 }
 
-// createColorGrid converts a flat array of colors into a 2D grid.
-func createColorGrid(colors []color.Color) [][]color.Color {
-	grid := make([][]color.Color, config.GridSize)
-	for i := 0; i < config.GridSize; i++ {
-		grid[i] = make([]color.Color, config.GridSize)
-		for j := 0; j < config.GridSize; j++ {
-			grid[i][j] = colors[i*config.GridSize+j]
+// createColorGrid converts a flat array of colors into a grid, filling it row by row.
+func createColorGrid(colors []tiles.Cell) tiles.Grid {
+	grid := tiles.New(config.GridSize, config.GridSize)
+	copy(grid.Cells, colors)
+	return grid
+}
+
+// createSlidingGrid converts a flat array of colors into a grid for the
+// sliding-puzzle variant. The empty cell is placed in the bottom-right corner,
+// as in a classic 15-puzzle.
+//
+// Sliding moves can only reach half of all arrangements of distinct tiles, but
+// the board has far more tiles than the palette has colors. Colors always
+// repeat, so every arrangement is reachable, see IsSlidingSolvable.
+func createSlidingGrid(colors []tiles.Cell) tiles.Grid {
+	return createColorGrid(append(colors, tiles.Empty))
+}
+
+// CanSolveSliding reports whether the tiles of a sliding puzzle can be slid
+// into their canonical solved arrangement, see slidingTarget.
+func CanSolveSliding(cells tiles.Grid) bool {
+	return IsSlidingSolvable(cells, slidingTarget(cells))
+}
+
+// slidingTarget returns the canonical solved arrangement for a sliding grid:
+// the tiles are grouped by color in row-major order and the empty cell is last.
+func slidingTarget(grid tiles.Grid) tiles.Grid {
+	var order []tiles.Cell
+	counts := grid.Counts()
+	seen := make(map[tiles.Cell]bool)
+	for _, c := range grid.Cells {
+		if c != tiles.Empty && !seen[c] {
+			order = append(order, c)
+			seen[c] = true
 		}
 	}
-	return grid
+
+	var colors []tiles.Cell
+	for _, c := range order {
+		for i := 0; i < counts[c]; i++ {
+			colors = append(colors, c)
+		}
+	}
+	target := tiles.New(grid.Width, grid.Height)
+	copy(target.Cells, colors)
+	return target
+}
+
+// IsSlidingSolvable reports whether the target arrangement can be reached from
+// the start arrangement by sliding tiles into the single empty cell.
+//
+// For distinct tiles this is the classic parity argument: a slide swaps the
+// empty cell with a neighbor, so the parity of the permutation must match the
+// parity of the distance the empty cell travels. If any color occurs at least
+// twice, two identical tiles can be exchanged to fix the parity, so every
+// arrangement with the same tiles is reachable.
+func IsSlidingSolvable(start, target tiles.Grid) bool {
+	if len(start.Cells) == 0 || start.Width != target.Width || start.Height != target.Height {
+		return false
+	}
+	cols := start.Width
+
+	// Both grids must contain the same tiles and exactly one empty cell.
+	startEmpty, targetEmpty := -1, -1
+	emptyCells := 0
+	for i := range start.Cells {
+		if start.Cells[i] == tiles.Empty {
+			startEmpty = i
+			emptyCells++
+		}
+		if target.Cells[i] == tiles.Empty {
+			targetEmpty = i
+		}
+	}
+	if emptyCells != 1 || targetEmpty == -1 {
+		return false
+	}
+	startCounts, targetCounts := start.Counts(), target.Counts()
+	hasDuplicates := false
+	for c, n := range startCounts {
+		if targetCounts[c] != n {
+			return false
+		}
+		if n > 1 {
+			hasDuplicates = true
+		}
+	}
+	if hasDuplicates {
+		return true
+	}
+
+	// All tiles are distinct: compare the permutation parity with the parity
+	// of the empty cell's taxicab distance.
+	targetIndex := make(map[tiles.Cell]int)
+	for i, c := range target.Cells {
+		if c != tiles.Empty {
+			targetIndex[c] = i
+		}
+	}
+	perm := make([]int, len(start.Cells))
+	for i, c := range start.Cells {
+		if c == tiles.Empty {
+			perm[i] = targetEmpty
+		} else {
+			perm[i] = targetIndex[c]
+		}
+	}
+	visited := make([]bool, len(perm))
+	cycles := 0
+	for i := range perm {
+		if visited[i] {
+			continue
+		}
+		cycles++
+		for j := i; !visited[j]; j = perm[j] {
+			visited[j] = true
+		}
+	}
+	permutationParity := (len(perm) - cycles) % 2
+
+	distance := abs(startEmpty/cols-targetEmpty/cols) + abs(startEmpty%cols-targetEmpty%cols)
+	return permutationParity == distance%2
 }
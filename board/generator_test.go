@@ -1,9 +1,9 @@
 package board
 
 import (
-	"image/color"
 	"testing"
 	"zenmojo/config"
+	"zenmojo/tiles"
 )
 
 func TestGenerateGroupSizes(t *testing.T) {
@@ -81,14 +81,7 @@ func TestAssignColorsToGroups(t *testing.T) {
 
 	// Test 2: Alle Farben müssen aus der Palette stammen
 	for i, c := range colors {
-		found := false
-		for _, paletteColor := range config.Palette {
-			if colorEqual(c, paletteColor) {
-				found = true
-				break
-			}
-		}
-		if !found {
+		if c == tiles.Empty || c.PaletteIndex() >= len(config.Palette) {
 			t.Errorf("Color at index %d is not from palette", i)
 		}
 	}
@@ -96,9 +89,9 @@ func TestAssignColorsToGroups(t *testing.T) {
 
 func TestCreateColorGrid(t *testing.T) {
 	totalTiles := config.GridSize * config.GridSize
-	colors := make([]color.Color, totalTiles)
+	colors := make([]tiles.Cell, totalTiles)
 	// Fülle das Array mit einer Testfarbe
-	testColor := config.Palette[0]
+	testColor := tiles.CellOf(0)
 	for i := range colors {
 		colors[i] = testColor
 	}
@@ -106,19 +99,17 @@ func TestCreateColorGrid(t *testing.T) {
 	grid := createColorGrid(colors)
 
 	// Test 1: Überprüfe die Grid-Dimensionen
-	if len(grid) != config.GridSize {
-		t.Errorf("Expected grid height %d, got %d", config.GridSize, len(grid))
+	if grid.Height != config.GridSize {
+		t.Errorf("Expected grid height %d, got %d", config.GridSize, grid.Height)
 	}
-	for i, row := range grid {
-		if len(row) != config.GridSize {
-			t.Errorf("Row %d: expected width %d, got %d", i, config.GridSize, len(row))
-		}
+	if grid.Width != config.GridSize {
+		t.Errorf("Expected grid width %d, got %d", config.GridSize, grid.Width)
 	}
 
 	// Test 2: Überprüfe, ob alle Farben korrekt übertragen wurden
 	for i := 0; i < config.GridSize; i++ {
 		for j := 0; j < config.GridSize; j++ {
-			if !colorEqual(grid[i][j], colors[i*config.GridSize+j]) {
+			if grid.At(i, j) != colors[i*config.GridSize+j] {
 				t.Errorf("Color mismatch at position (%d,%d)", i, j)
 			}
 		}
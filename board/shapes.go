@@ -0,0 +1,172 @@
+package board
+
+import (
+	"math/rand"
+	"sort"
+	"zenmojo/config"
+	"zenmojo/scoring"
+	"zenmojo/tiles"
+)
+
+// maxTilingSteps limits a single attempt to tile the board with shapes.
+// If an attempt gets stuck, a new one with a different random order is started.
+const maxTilingSteps = 20000
+
+// placedShape is a shape that was placed on the board while tiling it.
+type placedShape struct {
+	shape scoring.Shape // The placed variant of the shape
+	cells []scoring.Coordinate
+}
+
+// NewWithShapes creates a board for a target-shape puzzle, where every color
+// has to form a specific shape. It returns the board and the required shape of
+// each color. If sliding is set, one cell is left empty for the sliding-puzzle variant.
+//
+// The board is generated by first tiling it with the required shapes and then
+// shuffling the tiles. The tiling proves that all shapes can be packed at once.
+func NewWithShapes(sliding bool) (*Board, map[tiles.Cell]scoring.Shape) {
+	b := newBoard(tiles.Grid{}, config.Palette)
+
+	var placements []placedShape
+	for placements == nil {
+		placements = tileWithShapes(sliding)
+	}
+
+	// Give every shape its own color
+	availableColors := make([]tiles.Cell, len(config.Palette))
+	for i := range availableColors {
+		availableColors[i] = tiles.CellOf(i)
+	}
+	rand.Shuffle(len(availableColors), func(i, j int) {
+		availableColors[i], availableColors[j] = availableColors[j], availableColors[i]
+	})
+
+	shapes := make(map[tiles.Cell]scoring.Shape)
+	var colors []tiles.Cell
+	for i, p := range placements {
+		shapes[availableColors[i]] = p.shape
+		for range p.cells {
+			colors = append(colors, availableColors[i])
+		}
+	}
+
+	// Shuffle the tiles to turn the solved tiling into a puzzle
+	rand.Shuffle(len(colors), func(i, j int) {
+		colors[i], colors[j] = colors[j], colors[i]
+	})
+	if sliding {
+		b.swapMode = SwapSlide
+		b.cells = createSlidingGrid(colors)
+	} else {
+		b.cells = createColorGrid(colors)
+	}
+
+	return b, shapes
+}
+
+// tileWithShapes makes one attempt to cover the board with shapes from the
+// catalog, in random order and orientation. At most one shape per palette
+// color is used. It returns nil if the attempt failed.
+func tileWithShapes(sliding bool) []placedShape {
+	filled := make([][]bool, config.GridSize)
+	for r := range filled {
+		filled[r] = make([]bool, config.GridSize)
+	}
+	if sliding {
+		// Keep the cell for the gap free, matching createSlidingGrid
+		filled[config.GridSize-1][config.GridSize-1] = true
+	}
+
+	var candidates []scoring.Shape
+	for _, shape := range scoring.ShapeCatalog {
+		candidates = append(candidates, shape.Variants()...)
+	}
+
+	maxShapeSize := 0
+	for _, shape := range scoring.ShapeCatalog {
+		if shape.Size() > maxShapeSize {
+			maxShapeSize = shape.Size()
+		}
+	}
+
+	var placements []placedShape
+	steps := 0
+
+	var place func(cell, remaining int) bool
+	place = func(cell, remaining int) bool {
+		for cell < config.GridSize*config.GridSize && filled[cell/config.GridSize][cell%config.GridSize] {
+			cell++
+		}
+		if cell == config.GridSize*config.GridSize {
+			return true
+		}
+		// Give up on branches that would need more colors than the palette has.
+		if remaining > (len(config.Palette)-len(placements))*maxShapeSize {
+			return false
+		}
+		r, c := cell/config.GridSize, cell%config.GridSize
+
+		for _, idx := range shuffledBySize(candidates) {
+			steps++
+			if steps > maxTilingSteps {
+				return false
+			}
+
+			// The first cell of a normalized shape is its top-left-most cell in
+			// row-major order, so it has to cover the first free cell.
+			shape := candidates[idx]
+			offsetR, offsetC := r-shape.Cells[0].R, c-shape.Cells[0].C
+			cells := make([]scoring.Coordinate, 0, shape.Size())
+			fits := true
+			for _, sc := range shape.Cells {
+				cr, cc := sc.R+offsetR, sc.C+offsetC
+				if cr < 0 || cr >= config.GridSize || cc < 0 || cc >= config.GridSize || filled[cr][cc] {
+					fits = false
+					break
+				}
+				cells = append(cells, scoring.Coordinate{R: cr, C: cc})
+			}
+			if !fits {
+				continue
+			}
+
+			for _, cell := range cells {
+				filled[cell.R][cell.C] = true
+			}
+			placements = append(placements, placedShape{shape: shape, cells: cells})
+			if place(cell+1, remaining-len(cells)) {
+				return true
+			}
+			placements = placements[:len(placements)-1]
+			for _, cell := range cells {
+				filled[cell.R][cell.C] = false
+			}
+		}
+		return false
+	}
+
+	totalCells := config.GridSize * config.GridSize
+	if sliding {
+		totalCells--
+	}
+	if !place(0, totalCells) {
+		return nil
+	}
+	return placements
+}
+
+// shuffledBySize returns the indices of the shapes in a random order that tends
+// to put larger shapes first. This keeps the number of shapes, and therefore
+// colors, close to that of regular boards.
+func shuffledBySize(shapes []scoring.Shape) []int {
+	keys := make([]float64, len(shapes))
+	order := make([]int, len(shapes))
+	for i, shape := range shapes {
+		keys[i] = rand.Float64() * float64(shape.Size())
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return keys[order[i]] > keys[order[j]]
+	})
+	return order
+}
@@ -0,0 +1,66 @@
+// Package clock provides the time source for animations and other timed
+// effects. The game runs on a clock that advances with its ticks, so that
+// animations keep pace with the countdown and the game time, while tests use a
+// fake clock that only moves when they advance it, so that animations can be
+// stepped through deterministically without a running game loop.
+package clock
+
+import "time"
+
+// Clock tells the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the clock of the operating system.
+type System struct{}
+
+// Now returns the current wall-clock time.
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// Ticks is a clock that advances by one tick in every update of the game.
+// Like the game time, it runs at game speed rather than by the wall clock:
+// if updates are delayed, e.g. while the window is dragged, it waits for them.
+type Ticks struct {
+	start time.Time
+	ticks int
+	tps   int
+}
+
+// NewTicks returns a clock that starts at the current time and advances by
+// tps ticks per second.
+func NewTicks(tps int) *Ticks {
+	return &Ticks{start: time.Now(), tps: tps}
+}
+
+// Tick advances the clock by one tick.
+func (t *Ticks) Tick() {
+	t.ticks++
+}
+
+// Now returns the start time plus the ticks that have passed.
+func (t *Ticks) Now() time.Time {
+	return t.start.Add(time.Duration(t.ticks) * time.Second / time.Duration(t.tps))
+}
+
+// Fake is a clock that stands still until it is advanced.
+type Fake struct {
+	now time.Time
+}
+
+// NewFake returns a fake clock that starts at a fixed point in time.
+func NewFake() *Fake {
+	return &Fake{now: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// Now returns the time the fake clock is set to.
+func (f *Fake) Now() time.Time {
+	return f.now
+}
+
+// Advance moves the fake clock forward by d.
+func (f *Fake) Advance(d time.Duration) {
+	f.now = f.now.Add(d)
+}
@@ -0,0 +1,29 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeOnlyMovesWhenAdvanced(t *testing.T) {
+	f := NewFake()
+	start := f.Now()
+	if !f.Now().Equal(start) {
+		t.Fatal("Expected the fake clock to stand still")
+	}
+	f.Advance(1500 * time.Millisecond)
+	if elapsed := f.Now().Sub(start); elapsed != 1500*time.Millisecond {
+		t.Errorf("Expected 1.5s to have passed, got %v", elapsed)
+	}
+}
+
+func TestTicksAdvanceByTick(t *testing.T) {
+	c := NewTicks(60)
+	start := c.Now()
+	for i := 0; i < 90; i++ {
+		c.Tick()
+	}
+	if elapsed := c.Now().Sub(start); elapsed != 1500*time.Millisecond {
+		t.Errorf("Expected 90 ticks at 60 per second to take 1.5s, got %v", elapsed)
+	}
+}
@@ -5,22 +5,44 @@ import (
 	"image/color"
 	"image/draw"
 	"log"
+	"zenmojo/easing"
 
-	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/examples/resources/fonts"
-	"github.com/hajimehoshi/ebiten/v2/vector"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
 )
 
 const (
-	ScreenWidth           = 600
-	ScreenHeight          = 980
+	ScreenWidth           = 600  // Smallest canvas width of the portrait layout, and the width of the side panel in landscape
+	ScreenHeight          = 980  // Smallest canvas height of the portrait layout
+	LandscapeWidth        = 1200 // Smallest canvas width of the landscape layout, with the graph and distribution beside the board
+	LandscapeHeight       = 660  // Smallest canvas height of the landscape layout
 	GridSize              = 10
 	SquareSize            = 48
 	Gap                   = 8
-	SwapAnimationDuration = 0.2 // Base duration for a swap animation in seconds
-	StretchFactor         = 1.0 // Multiplies the animation duration. Higher values are slower.
+	SwapAnimationDuration = 0.2              // Base duration for a swap animation of adjacent tiles in seconds
+	SwapAnimationPerCell  = 0.04             // Additional duration for every further cell the tiles travel, in seconds
+	SwapAnimationMax      = 0.5              // Upper limit of the duration of a swap animation in seconds
+	StretchFactor         = 1.0              // Multiplies the animation duration. Higher values are slower.
+	SwapEasing            = easing.EaseInOut // Default easing of swap animations, can be changed in the settings menu
+	SwapArc               = 0.25             // Height of the arcs the swapped tiles travel on, relative to their distance. 0 moves them in a straight line.
+	SwapLiftScale         = 0.12             // How much a swapped tile grows at the top of its arc
+	SwapLiftShadow        = 6                // Offset of the shadow of a swapped tile at the top of its arc, in pixels
+
+	TPS = 60 // Game ticks per second. Animations, the countdown and the game time all advance by ticks.
+
+	ChallengeMarginPercent = 25 // Extra moves on top of par for a challenge, in percent of par
+	ChallengeMinMargin     = 5  // Minimum number of extra moves on top of par for a challenge
+	TimeAttackSeconds      = 90 // Time limit of a board in time-attack mode
+
+	ZenTransitionDuration = 1.2 // Duration of the transition between two boards in zen mode, in seconds
+	GlowDuration          = 0.8 // Duration of the glow around a group that starts scoring, in seconds
+	InputQueueLength      = 4   // Number of clicks on the board that are kept while a move is animating
+	DragThreshold         = 6   // Distance in pixels the mouse has to move with a pressed tile before the tile is dragged
+	KeyRepeatDelay        = 15  // Number of ticks a key that moves the board cursor is held before it repeats
+	KeyRepeatInterval     = 4   // Number of ticks between repeats of a held key
+	LongPressDuration     = 0.5 // Duration a finger has to rest on a tile to show a hint, in seconds
+	StickDeadZone         = 0.5 // How far a gamepad stick has to be tilted to move the board cursor, from 0 to 1
 )
 
 var (
@@ -79,16 +101,17 @@ var (
 		DarkGreen:  White,
 	}
 
-	GridWidth   int
-	GridHeight  int
-	GridOriginX int
-	GridOriginY int
+	GridWidth  int
+	GridHeight int
 
 	BackgroundColor = color.RGBA{R: 245, G: 239, B: 230, A: 255}
 	HatchingColor   = color.RGBA{R: 203, G: 220, B: 235, A: 255} // Light purple
 	ShadowColor     = color.RGBA{R: 0, G: 0, B: 0, A: 128}
-
-	HatchingPattern *ebiten.Image
+	DimColor        = color.RGBA{R: 196, G: 191, B: 184, A: 200} // Translucent background drawn over tiles that are not highlighted
+	OutlineColor    = color.RGBA{R: 60, G: 60, B: 60, A: 255}    // Frame around scoring groups
+	GlowColor       = color.RGBA{R: 255, G: 215, B: 0, A: 255}   // Glow around groups that just started scoring
+	DropTargetColor = color.RGBA{R: 0, G: 122, B: 255, A: 255}   // Frame around the tile a dragged tile would be swapped with
+	CursorColor     = color.RGBA{R: 27, G: 24, B: 51, A: 255}    // Frame of the board cursor moved with the keyboard
 
 	MTextFace  font.Face
 	STextFace  font.Face // Small font for UI annotations
@@ -101,15 +124,6 @@ func init() {
 	// Calculate grid dimensions
 	GridWidth = GridSize*SquareSize + (GridSize-1)*Gap
 	GridHeight = GridSize*SquareSize + (GridSize-1)*Gap
-	GridOriginX = (ScreenWidth - GridWidth) / 2
-	GridOriginY = (ScreenHeight - GridHeight) / 2
-
-	// Create graphical assets
-	patternSize := 20
-	HatchingPattern = ebiten.NewImage(patternSize, patternSize)
-	for i := -patternSize; i < patternSize; i += 4 {
-		vector.StrokeLine(HatchingPattern, float32(i), 0, float32(i+patternSize), float32(patternSize), 1, HatchingColor, false)
-	}
 
 	// Load font
 	ttf, err := opentype.Parse(fonts.MPlus1pRegular_ttf)
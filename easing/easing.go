@@ -0,0 +1,72 @@
+// Package easing maps the linear progress of an animation to the position of
+// the animated object, so that it can speed up, slow down or overshoot
+// instead of moving at constant speed.
+package easing
+
+import "math"
+
+// Easing is a curve that an animation follows.
+type Easing int
+
+const (
+	// Linear moves at constant speed.
+	Linear Easing = iota
+	// EaseInOut starts slowly, speeds up and slows down again towards the end.
+	EaseInOut
+	// Back overshoots the end a little and then settles back.
+	Back
+	// Spring overshoots the end and swings around it a few times with
+	// decreasing amplitude before it comes to rest.
+	Spring
+)
+
+// String returns a human-readable name for the easing.
+func (e Easing) String() string {
+	switch e {
+	case EaseInOut:
+		return "Ease in-out"
+	case Back:
+		return "Back"
+	case Spring:
+		return "Spring"
+	default:
+		return "Linear"
+	}
+}
+
+// Next returns the easing following e, wrapping around after the last one.
+func (e Easing) Next() Easing {
+	return (e + 1) % (Spring + 1)
+}
+
+// Apply returns the eased position for the linear progress t. Progress outside
+// [0, 1] is clamped, and the result is exactly 0 at the start and 1 at the end.
+// In between, Back and Spring may leave [0, 1].
+func (e Easing) Apply(t float64) float64 {
+	if t <= 0 {
+		return 0
+	}
+	if t >= 1 {
+		return 1
+	}
+	switch e {
+	case EaseInOut:
+		// Cubic ease-in-out
+		if t < 0.5 {
+			return 4 * t * t * t
+		}
+		u := -2*t + 2
+		return 1 - u*u*u/2
+	case Back:
+		// Ease-out with an overshoot of about 10 percent
+		const c1 = 1.70158
+		const c3 = c1 + 1
+		u := t - 1
+		return 1 + c3*u*u*u + c1*u*u
+	case Spring:
+		// A damped oscillation around the end
+		return 1 - math.Exp(-6*t)*math.Cos(3*math.Pi*t)
+	default:
+		return t
+	}
+}
@@ -0,0 +1,75 @@
+package easing
+
+import (
+	"math"
+	"testing"
+	"testing/quick"
+)
+
+func TestApplyEndpoints(t *testing.T) {
+	for e := Linear; e <= Spring; e++ {
+		for _, tc := range []struct {
+			t, expected float64
+		}{
+			{-0.5, 0},
+			{0, 0},
+			{1, 1},
+			{1.5, 1},
+		} {
+			if got := e.Apply(tc.t); got != tc.expected {
+				t.Errorf("%s.Apply(%v) = %v, expected %v", e, tc.t, got, tc.expected)
+			}
+		}
+	}
+}
+
+// TestApplyContinuousAtEnd checks that no easing jumps when the animation
+// ends, which would show as a tile snapping into place.
+func TestApplyContinuousAtEnd(t *testing.T) {
+	for e := Linear; e <= Spring; e++ {
+		if got := e.Apply(0.999); math.Abs(got-1) > 0.01 {
+			t.Errorf("%s.Apply(0.999) = %v, expected close to 1", e, got)
+		}
+	}
+}
+
+func TestApplyMonotonic(t *testing.T) {
+	for _, e := range []Easing{Linear, EaseInOut} {
+		f := func(a, b uint16) bool {
+			t1, t2 := float64(a)/math.MaxUint16, float64(b)/math.MaxUint16
+			if t1 > t2 {
+				t1, t2 = t2, t1
+			}
+			return e.Apply(t1) <= e.Apply(t2)
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Errorf("%s: %v", e, err)
+		}
+	}
+}
+
+func TestApplyOvershoots(t *testing.T) {
+	for _, e := range []Easing{Back, Spring} {
+		overshoots := false
+		for i := 1; i < 100; i++ {
+			if e.Apply(float64(i)/100) > 1 {
+				overshoots = true
+			}
+		}
+		if !overshoots {
+			t.Errorf("Expected %s to overshoot the end", e)
+		}
+	}
+}
+
+func TestEasingNextCyclesThroughAll(t *testing.T) {
+	seen := make(map[Easing]bool)
+	e := Linear
+	for i := 0; i <= int(Spring); i++ {
+		seen[e] = true
+		e = e.Next()
+	}
+	if e != Linear || len(seen) != int(Spring)+1 {
+		t.Errorf("Expected Next to visit all %d easings and wrap around, got %v", Spring+1, seen)
+	}
+}
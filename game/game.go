@@ -1,69 +1,350 @@
 package game
 
 import (
+	"fmt"
+	"image"
 	"image/color"
 	"log"
+	"slices"
 	"time"
-	"zenmojo/audio"
 	"zenmojo/board"
+	"zenmojo/clock"
 	"zenmojo/config"
+	"zenmojo/easing"
+	"zenmojo/layout"
+	"zenmojo/scene"
 	"zenmojo/scoring"
 	"zenmojo/sharing"
-	"zenmojo/view"
-
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
-	"golang.design/x/clipboard"
+	"zenmojo/solver"
+	"zenmojo/stats"
+	"zenmojo/tiles"
 )
 
 // Game holds the main game state.
 type Game struct {
 	board            *board.Board
-	audioManager     *audio.Manager
+	input            Input
+	screen           Screen
+	clipboard        Clipboard
+	sound            Sound
+	window           Window
+	clock            clock.Clock   // Times animations and effects
+	layout           layout.Layout // Arrangement of the screen, for finding the cells under the mouse and fingers
+	mode             Mode
+	swapMode         board.SwapMode
+	easing           easing.Easing // Easing of swap animations
 	score            int
 	maxScore         int
-	moveCount        int
+	zenTarget        int                        // Score at which a zen board is complete, see solver.TargetScore
+	scorer           *scoring.IncrementalScorer // Keeps the score of the board up to date and scores hypothetical swaps
+	moveCost         int
+	budget           int                          // Move budget in challenge mode, 0 otherwise
+	ruleSetID        string                       // Id of the registered scoring rule set, see scoring.RuleSets
+	shapes           map[tiles.Cell]scoring.Shape // Required shape of each color in target-shape puzzles
+	timer            countdown
+	ticks            int // Game ticks played on the current board
+	isGameOver       bool
+	isSettingsOpen   bool
+	isNewBest        bool // Whether the finished game set a new best for its mode
+	stats            *stats.Stats
+	statsPath        string
+	session          stats.Session     // Boards completed in zen mode
+	transition       *scene.Transition // Animation between two boards in zen mode
 	scoreHistory     []int
-	colorCounts      map[color.Color]int
+	replay           Replay                                 // Moves applied to the current board
+	undone           []Move                                 // Undone moves that can be redone, the last one first
+	animating        moveKind                               // How the move that is animating came about
+	breakdown        map[color.Color]scoring.ColorBreakdown // Score contribution of each color
+	pinnedColor      color.Color                            // Color highlighted by clicking its miniature, or nil
+	scoringGroups    map[color.Color]scoring.Group          // Groups that score as a line, rectangle or required shape
+	glowStart        map[color.Color]time.Time              // When each scoring group started scoring
+	preview          *scene.Preview                         // Swap under the mouse cursor, or nil
+	queuedCells      []image.Point                          // Cells selected while a move was animating
+	drag             *dragGesture                           // Tile pressed with the mouse or a finger, or nil
+	touch            *touchGesture                          // Finger on the screen, or nil
+	cursorX, cursorY int                                    // Cell of the board cursor, moved with the keyboard
+	cursorVisible    bool                                   // Whether the keyboard is used and the board cursor shown
+	settingsFocus    int                                    // Entry of the settings menu chosen by the select action
+	gamepads         gamepads
 	shareCode        string
 	isCustomBoard    bool
 	copyFeedback     string
 	copyFeedbackTime time.Time
 }
 
-// NewGame initializes a new game.
-func NewGame(audioManager *audio.Manager) *Game {
+// NewGame initializes a new game on the given platform.
+func NewGame(p Platform) *Game {
 	g := &Game{
-		audioManager: audioManager,
+		input:     p.Input,
+		screen:    p.Screen,
+		clipboard: p.Clipboard,
+		sound:     p.Sound,
+		window:    p.Window,
+		clock:     p.Clock,
+		layout:    layout.New(config.ScreenWidth, config.ScreenHeight, false),
+		easing:    config.SwapEasing,
+		ruleSetID: scoring.DefaultRuleSetID,
+		stats:     stats.New(),
+	}
+
+	// Load the statistics of earlier sessions. Without them, the game still works
+	// but results are only kept for this session.
+	if path, err := stats.DefaultPath(); err != nil {
+		log.Printf("Error locating statistics: %v", err)
+	} else if s, err := stats.Load(path); err != nil {
+		log.Printf("Error loading statistics: %v", err)
+	} else {
+		g.stats = s
+		g.statsPath = path
 	}
+
+	loadHouseRules()
+
 	g.startNewGame(nil) // Start with a random board
 	return g
 }
 
+// loadHouseRules registers the declarative rule sets from the user's rules
+// directory, so they can be selected like the built-in ones.
+func loadHouseRules() {
+	dir, err := scoring.DefaultRulesDir()
+	if err != nil {
+		log.Printf("Error locating house rules: %v", err)
+		return
+	}
+	ruleSets, err := scoring.LoadRuleSetDir(dir)
+	if err != nil {
+		log.Printf("Error loading house rules: %v", err)
+	}
+	for _, rs := range ruleSets {
+		if err := scoring.Register(rs); err != nil {
+			log.Printf("Error registering house rules: %v", err)
+		}
+	}
+}
+
 // startNewGame resets the game state with a new board.
-// If a grid is provided, it uses that; otherwise, it creates a random one.
-func (g *Game) startNewGame(grid [][]color.Color) {
-	if grid == nil {
-		g.board = board.New()
+// If cells are provided, it uses them; otherwise, it creates a random board.
+func (g *Game) startNewGame(cells *tiles.Grid) {
+	g.loadBoard(cells)
+
+	g.endSession()
+	g.transition = nil
+	g.moveCost = 0
+	g.isGameOver = false
+	g.scoreHistory = []int{g.score}
+
+	// A shared challenge keeps its budget. Otherwise it's derived from the solver's par.
+	if g.mode != ModeChallenge {
+		g.budget = 0
+	} else if cells == nil || g.budget == 0 {
+		g.budget = challengeBudget(g.board.Cells(), g.rules(), g.swapMode)
+	}
+
+	g.updateShareCode()
+	g.startReplay()
+}
+
+// loadBoard replaces the board and resets the per-board state.
+// If cells are provided, it uses them together with the current required shapes;
+// otherwise, it creates a random board.
+func (g *Game) loadBoard(cells *tiles.Grid) {
+	if cells == nil {
+		switch {
+		case g.isShapeRuleSet():
+			g.board, g.shapes = board.NewWithShapes(g.swapMode == board.SwapSlide)
+		case g.swapMode == board.SwapSlide:
+			g.board, g.shapes = board.NewSliding(), nil
+		default:
+			g.board, g.shapes = board.New(), nil
+		}
 		g.isCustomBoard = false
 	} else {
-		g.board = board.NewFromGrid(grid)
+		g.board = board.NewFromCells(*cells)
 		g.isCustomBoard = true
 	}
+	g.board.SetSwapMode(g.swapMode)
+	g.board.SetClock(g.clock)
+	g.board.SetEasing(g.easing)
+	g.pinnedColor = nil // The colors of the new board may differ
+	g.preview = nil
+	g.queuedCells = nil
+	g.animating = movePlayed
+	g.drag = nil
+	if g.touch != nil {
+		g.touch.cancelled = true // The touch started on the previous board
+	}
 
 	// Update the window icon to match a tile from the new board.
-	if g.board.Grid()[0][0] != nil {
-		ebiten.SetWindowIcon(config.CreateTileIcons(g.board.Grid()[0][0]))
+	if tile := g.board.Cells().At(0, 0); tile != tiles.Empty {
+		g.window.SetIcon(config.CreateTileIcons(tile.Color(g.board.Palette())))
 	}
 
-	g.score = scoring.CalculateScore(g.board.Grid(), scoring.StandardRuleSet{})
-	g.maxScore = scoring.CalculateMaxPossibleScore(g.board.Grid())
-	g.moveCount = 0
-	g.scoreHistory = []int{g.score}
-	g.colorCounts = scoring.CountColors(g.board.Grid())
+	// The rules only change together with the board, so the scorer lives as long as the board.
+	g.scorer = scoring.NewIncrementalScorer(g.board.Cells(), g.rules())
+	g.scoringGroups = nil // Groups of a new board don't glow
+	g.updateScore()
+	g.maxScore = g.rules().MaxPossibleScore(g.board.Cells())
+	if g.mode == ModeZen {
+		// The maximum score can be out of reach when the best shapes don't fit together.
+		g.zenTarget = solver.TargetScore(g.board.Cells(), g.rules())
+	}
+	g.ticks = 0
+	g.timer = newCountdown(config.TimeAttackSeconds, config.TPS)
+}
+
+// ruleSet returns the selected rule set.
+func (g *Game) ruleSet() scoring.RuleSet {
+	if rs, ok := scoring.LookupRuleSet(g.ruleSetID); ok {
+		return rs
+	}
+	rs, _ := scoring.LookupRuleSet(scoring.DefaultRuleSetID)
+	return rs
+}
 
-	// Generate the share code for this board
-	code, err := sharing.Encode(g.board.Grid())
+// isShapeRuleSet reports whether the selected rules require every color to form a specific shape.
+func (g *Game) isShapeRuleSet() bool {
+	_, ok := g.ruleSet().Rule.(scoring.ShapeRuleSet)
+	return ok
+}
+
+// updateScore takes the score, its breakdown by color and the scoring groups
+// from the scorer. Groups that start scoring after a move begin to glow.
+func (g *Game) updateScore() {
+	g.score = g.scorer.Score()
+	g.breakdown = g.scorer.Breakdown(g.board.Palette())
+
+	canGlow := g.scoringGroups != nil
+	previous := g.scoringGroups
+	g.scoringGroups = make(map[color.Color]scoring.Group)
+	if !canGlow {
+		g.glowStart = make(map[color.Color]time.Time)
+	}
+	for c, b := range g.breakdown {
+		if !b.Complete || b.Points == 0 {
+			continue
+		}
+		// Partial credit for irregular groups is not shown, but required shapes are.
+		if b.Kind == scoring.KindIrregular && g.shapes == nil {
+			continue
+		}
+		g.scoringGroups[c] = b.Groups[0]
+		if _, wasScoring := previous[c]; canGlow && !wasScoring {
+			g.glowStart[c] = g.clock.Now()
+		}
+	}
+}
+
+// updatePreview determines the swap that a click at the mouse cursor would make,
+// and how it would change the score. The delta is only computed again when the
+// cursor moves to another swap.
+func (g *Game) updatePreview(x1, y1, x2, y2 int, ok bool) {
+	if !ok || g.isGameOver {
+		g.preview = nil
+		return
+	}
+	if p := g.preview; p != nil && p.X1 == x1 && p.Y1 == y1 && p.X2 == x2 && p.Y2 == y2 {
+		return
+	}
+	g.preview = &scene.Preview{
+		X1: x1, Y1: y1, X2: x2, Y2: y2,
+		Delta: g.scorer.SwapDelta(y1, x1, y2, x2),
+	}
+}
+
+// outlines returns the frames of the scoring groups, with the strength of their glow.
+func (g *Game) outlines() []scene.Outline {
+	var outlines []scene.Outline
+	for c, group := range g.scoringGroups {
+		glow := 0.0
+		if start, ok := g.glowStart[c]; ok {
+			glow = 1 - g.clock.Now().Sub(start).Seconds()/config.GlowDuration
+			if glow < 0 {
+				glow = 0
+			}
+		}
+		outlines = append(outlines, scene.Outline{
+			MinR: group.MinR, MaxR: group.MaxR,
+			MinC: group.MinC, MaxC: group.MaxC,
+			Glow: glow,
+		})
+	}
+	return outlines
+}
+
+// rules returns the scoring rules of the current board.
+func (g *Game) rules() scoring.ScoringRule {
+	rule := g.ruleSet().Rule
+	if shapeRules, ok := rule.(scoring.ShapeRuleSet); ok {
+		shapeRules.Shapes = g.shapes
+		return shapeRules
+	}
+	return rule
+}
+
+// loadSharedRules selects the rule set and required shapes of a shared board
+// with the given cells. Codes without a rule set are scored by the default rules,
+// or by the target-shape rules if they carry shapes.
+func (g *Game) loadSharedRules(opts sharing.Options, cells tiles.Grid) error {
+	id := opts.Rules
+	if id == "" {
+		id = scoring.DefaultRuleSetID
+		if len(opts.Shapes) > 0 {
+			id = "shapes"
+		}
+	}
+	if _, ok := scoring.LookupRuleSet(id); !ok {
+		return fmt.Errorf("unknown rule set %q", id)
+	}
+	shapes, err := decodeShapes(opts.Shapes, cells)
+	if err != nil {
+		return err
+	}
+	g.ruleSetID = id
+	g.shapes = shapes
+	return nil
+}
+
+// checkSwapMode returns an error if a shared board can't be played in the swap
+// mode shared with it: the mode must be known, a sliding puzzle needs exactly
+// one empty cell and has to be solvable, and the other modes can't have any
+// empty cells.
+func checkSwapMode(cells tiles.Grid, mode board.SwapMode) error {
+	if !mode.IsValid() {
+		return fmt.Errorf("unknown swap mode %d", mode)
+	}
+	emptyCells := cells.Count(tiles.Empty)
+	if mode == board.SwapSlide && emptyCells != 1 {
+		return fmt.Errorf("a sliding puzzle needs exactly one empty cell, the board has %d", emptyCells)
+	}
+	if mode == board.SwapSlide && !board.CanSolveSliding(cells) {
+		return fmt.Errorf("the tiles of the sliding puzzle can't be slid into place")
+	}
+	if mode != board.SwapSlide && emptyCells != 0 {
+		return fmt.Errorf("only a sliding puzzle can have empty cells, the board has %d", emptyCells)
+	}
+	return nil
+}
+
+// updateShareCode generates the share code for the current board. It includes
+// the settings needed to play the board under the same conditions.
+func (g *Game) updateShareCode() {
+	opts := sharing.Options{
+		SwapMode: int(g.swapMode),
+		Budget:   g.budget,
+	}
+	if g.ruleSetID != scoring.DefaultRuleSetID {
+		opts.Rules = g.ruleSetID
+	}
+	if g.shapes != nil {
+		opts.Shapes = make(map[tiles.Cell]int)
+		for c, shape := range g.shapes {
+			if id, ok := scoring.ShapeID(shape); ok {
+				opts.Shapes[c] = id
+			}
+		}
+	}
+	code, err := sharing.EncodeTilesWithOptions(g.board.Cells(), opts)
 	if err != nil {
 		log.Printf("Error generating share code: %v", err)
 		g.shareCode = "Error"
@@ -72,61 +353,649 @@ func (g *Game) startNewGame(grid [][]color.Color) {
 	}
 }
 
-// Update proceeds the game state.
-func (g *Game) Update() error {
+// nextZenBoard completes the current board of a zen session and flows into a
+// new one. The score and the score history carry over.
+func (g *Game) nextZenBoard() {
+	result := stats.Result{
+		Mode:     g.mode.String(),
+		Score:    g.score,
+		MaxScore: g.maxScore,
+		Moves:    g.moveCost - g.session.Moves,
+		Ticks:    g.ticks,
+	}
+	g.session.AddBoard(result)
+
+	g.transition = scene.NewTransition(g.board, config.ZenTransitionDuration, g.clock.Now())
+	g.loadBoard(nil)
+	g.updateShareCode()
+	g.startReplay()
+	g.scoreHistory = append(g.scoreHistory, g.totalScore())
+}
+
+// endSession records a zen session that completed at least one board in the
+// statistics, as a single game, and starts a new one. Sessions end with a new
+// game, e.g. after switching the game mode, and when the game is closed.
+func (g *Game) endSession() {
+	if g.session.Boards > 0 {
+		g.stats.Record(g.session.Result(ModeZen.String()))
+		g.saveStats()
+	}
+	g.session = stats.Session{}
+}
+
+// totalScore returns the score including all boards completed in this session.
+func (g *Game) totalScore() int {
+	return g.session.Score + g.score
+}
+
+// restartBoard starts the current board over from its initial layout.
+func (g *Game) restartBoard() {
+	isCustom := g.isCustomBoard
+	cells, _, err := sharing.DecodeTilesWithOptions(g.shareCode)
+	if err != nil {
+		g.startNewGame(nil)
+		return
+	}
+	if _, _, hasEmpty := board.NewFromCells(cells).EmptyCell(); hasEmpty != (g.swapMode == board.SwapSlide) {
+		// The layout does not fit the swap mode, so a fresh board is generated.
+		g.startNewGame(nil)
+		return
+	}
+	g.startNewGame(&cells)
+	g.isCustomBoard = isCustom
+}
+
+// checkGameOver ends a challenge once the budget is used up, a time attack once
+// the time has expired, and both once the board is solved.
+func (g *Game) checkGameOver() {
+	if g.isGameOver {
+		return
+	}
+	switch g.mode {
+	case ModeChallenge:
+		g.isGameOver = g.moveCost >= g.budget || g.score >= g.maxScore
+	case ModeTimeAttack:
+		g.isGameOver = g.timer.Expired() || g.score >= g.maxScore
+	}
+	if g.isGameOver {
+		g.recordResult()
+	}
+}
+
+// recordResult adds the finished game to the statistics and saves them.
+func (g *Game) recordResult() {
+	g.isNewBest = g.stats.Record(stats.Result{
+		Mode:     g.mode.String(),
+		Score:    g.score,
+		MaxScore: g.maxScore,
+		Moves:    g.moveCost,
+		Ticks:    g.ticks,
+	})
+	g.saveStats()
+}
+
+// saveStats writes the statistics to disk, if they were loaded from there.
+func (g *Game) saveStats() {
+	if g.statsPath != "" {
+		if err := g.stats.Save(g.statsPath); err != nil {
+			log.Printf("Error saving statistics: %v", err)
+		}
+	}
+}
+
+// showFeedback displays a short message in place of the share code.
+func (g *Game) showFeedback(message string) {
+	g.copyFeedback = message
+	g.copyFeedbackTime = g.clock.Now()
+}
+
+// cycleSwapMode switches to the next swap mode. The board restarts so that all
+// moves are priced the same way. Sliding puzzles have no par, so a challenge
+// turns into a classic game when the tiles start to slide.
+func (g *Game) cycleSwapMode() {
+	g.swapMode = g.swapMode.Next()
+	g.budget = 0 // The budget depends on the swap mode
+	if g.swapMode == board.SwapSlide && g.mode == ModeChallenge {
+		g.mode = ModeClassic
+	}
+	g.restartBoard()
+	g.showFeedback(fmt.Sprintf("Swap mode: %s", g.swapMode))
+}
+
+// cycleMode switches to the next game mode and restarts the board in the new mode.
+// Challenges are skipped for sliding puzzles, which have no par.
+func (g *Game) cycleMode() {
+	g.mode = g.mode.Next()
+	if g.mode == ModeChallenge && g.swapMode == board.SwapSlide {
+		g.mode = g.mode.Next()
+	}
+	g.budget = 0
+	g.restartBoard()
+	g.showFeedback(fmt.Sprintf("Game mode: %s", g.mode))
+}
+
+// cycleRules switches to the next registered rule set. The board restarts under
+// the new rules, unless they differ in whether colors have required shapes.
+// Then a new board is needed.
+func (g *Game) cycleRules() {
+	hadShapes := g.isShapeRuleSet()
+	g.ruleSetID = scoring.NextRuleSet(g.ruleSetID)
+	g.budget = 0 // The budget depends on the rules
+	if g.isShapeRuleSet() == hadShapes {
+		g.restartBoard()
+	} else {
+		g.startNewGame(nil)
+	}
+	g.showFeedback(fmt.Sprintf("Rules: %s", g.ruleSet().Name))
+}
+
+// cycleEasing switches to the next easing of swap animations. Unlike the other
+// settings, it does not affect play, so the board is kept.
+func (g *Game) cycleEasing() {
+	g.easing = g.easing.Next()
+	g.board.SetEasing(g.easing)
+	g.showFeedback(fmt.Sprintf("Animation: %s", g.easing))
+}
+
+// settingsOptions returns the entries of the settings menu. Each entry shows
+// the current value of a setting and the key that switches it; clicking it
+// switches to the next value as well.
+func (g *Game) settingsOptions() []string {
+	return []string{
+		fmt.Sprintf("Rules: %s (%s)", g.ruleSet().Name, keyHint(actionRules)),
+		fmt.Sprintf("Swap mode: %s (%s)", g.swapMode, keyHint(actionSwapMode)),
+		fmt.Sprintf("Game mode: %s (%s)", g.mode, keyHint(actionGameMode)),
+		fmt.Sprintf("Animation: %s (%s)", g.easing, keyHint(actionAnimation)),
+		fmt.Sprintf("Fullscreen: %s (%s)", onOff(g.window.IsFullscreen()), keyHint(actionFullscreen)),
+		fmt.Sprintf("New board (%s)", keyHint(actionNewGame)),
+		fmt.Sprintf("Close (%s)", keyHint(actionCancel)),
+	}
+}
+
+// updateSettings handles clicks on the settings menu, and the actions that
+// move the focus through its entries and choose the focused one.
+func (g *Game) updateSettings(actions []action) {
+	numOptions := len(g.settingsOptions())
+	for _, a := range actions {
+		switch a {
+		case actionUp:
+			g.settingsFocus = (g.settingsFocus + numOptions - 1) % numOptions
+		case actionDown:
+			g.settingsFocus = (g.settingsFocus + 1) % numOptions
+		case actionSelect:
+			g.chooseSetting(g.settingsFocus)
+		}
+	}
+	if g.input.MousePressDuration() == 1 {
+		x, y := g.input.CursorPosition()
+		g.chooseSetting(g.screen.SettingsOptionAt(g.layout, x, y, numOptions))
+	}
+}
+
+// chooseSetting switches the setting with the given index in settingsOptions
+// to its next value, or closes the menu.
+func (g *Game) chooseSetting(i int) {
+	switch i {
+	case 0:
+		g.cycleRules()
+	case 1:
+		g.cycleSwapMode()
+	case 2:
+		g.cycleMode()
+	case 3:
+		g.cycleEasing()
+	case 4:
+		g.toggleFullscreen()
+	case 5:
+		g.performMenuAction(actionNewGame)
+	case 6:
+		g.isSettingsOpen = false
+	}
+}
+
+// Update proceeds the game state by one tick, with the input of the platform.
+func (g *Game) Update() {
+	actions := append(keyActions(g.input), g.gamepads.update(g.input, g.showFeedback)...)
+
+	// The window can be made fullscreen at any time, even during a move
+	if slices.Contains(actions, actionFullscreen) {
+		g.toggleFullscreen()
+	}
+
 	// Check for pasted share code
-	if ebiten.IsKeyPressed(ebiten.KeyControl) && inpututil.IsKeyJustPressed(ebiten.KeyV) {
-		pastedText := string(clipboard.Read(clipboard.FmtText))
-		if grid, err := sharing.Decode(pastedText); err == nil {
-			g.startNewGame(grid)
-			return nil // Restarted game, skip rest of update
+	if slices.Contains(actions, actionPaste) && g.paste() {
+		return // Restarted game, skip rest of update
+	}
+
+	// Actions on the whole game wait for a move to finish; the game is paused
+	// while the settings menu is open.
+	if !g.board.IsAnimating {
+		for _, a := range actions {
+			if g.performMenuAction(a) {
+				return
+			}
 		}
 	}
 
+	if g.isSettingsOpen {
+		g.preview = nil
+		g.drag = nil
+		g.updateTouch()
+		g.updateSettings(actions)
+		return
+	}
+
+	if g.advance() {
+		g.drag = nil
+		g.updateTouch()
+		if g.touch == nil && g.input.MousePressDuration() == 1 {
+			g.busyClick(g.input.CursorPosition())
+		}
+		// Selections during a move are played once it has finished, so fast players don't lose them
+		if g.transition == nil {
+			for _, a := range actions {
+				if a == actionSelect && g.cursorVisible {
+					g.queueSelection(g.cursorX, g.cursorY)
+				} else if a.repeats() {
+					g.performBoardAction(a)
+				}
+			}
+		}
+		return
+	}
+
+	for _, a := range actions {
+		g.performBoardAction(a)
+	}
+
+	g.updateTouch()
+	x, y := g.pointer()
+	if g.touch == nil {
+		// The mouse is ignored while a finger touches the screen, in case touches are reported as clicks as well
+		if g.input.MousePressDuration() == 1 {
+			g.click(x, y)
+			g.startDrag(x, y)
+		}
+		g.updateDrag(x, y, g.input.MousePressDuration() > 0)
+	}
+	switch {
+	case g.touch != nil && g.touch.hinting:
+		// Keep showing the hint while the finger rests on the tile
+	case g.drag != nil && g.drag.active:
+		g.updatePreview(g.dropTarget(x, y))
+	case g.cursorVisible:
+		g.updatePreview(g.board.SwapWith(g.cursorX, g.cursorY))
+	default:
+		g.updatePreview(g.board.SwapAt(g.layout, x, y))
+	}
+
+	// Reset copy feedback message after a delay
+	if g.copyFeedback != "" && g.clock.Now().Sub(g.copyFeedbackTime).Seconds() > 1.5 {
+		g.copyFeedback = ""
+	}
+}
+
+// Close ends the game when its window is closed. A zen session that is
+// still running is recorded.
+func (g *Game) Close() {
+	g.endSession()
+}
+
+// paste loads the share code in the clipboard, with the settings it carries.
+// It returns true if a new game was started. A code that can't be played is
+// rejected with a short message, and the current game goes on.
+func (g *Game) paste() bool {
+	cells, opts, err := sharing.DecodeTilesWithOptions(g.clipboard.Read())
+	if err != nil {
+		g.showFeedback("No board to paste")
+		return false
+	}
+	if err := checkSwapMode(cells, board.SwapMode(opts.SwapMode)); err != nil {
+		log.Printf("Error loading shared board: %v", err)
+		g.showFeedback("Can't play this board")
+		return false
+	}
+	if board.SwapMode(opts.SwapMode) == board.SwapSlide && (opts.Budget > 0 || g.mode == ModeChallenge) {
+		// Sliding puzzles have no par, so they are never played as challenges.
+		log.Printf("Error loading shared board: a sliding puzzle can't be a challenge")
+		g.showFeedback("Can't play this board")
+		return false
+	}
+	if err := g.loadSharedRules(opts, cells); err != nil {
+		log.Printf("Error loading shared rules: %v", err)
+		g.showFeedback("Can't play this board")
+		return false
+	}
+	g.swapMode = board.SwapMode(opts.SwapMode)
+	g.budget = opts.Budget
+	if opts.Budget > 0 {
+		g.mode = ModeChallenge
+	}
+	g.startNewGame(&cells)
+	return true
+}
+
+// copyShareCode copies the share code of the current board to the clipboard.
+func (g *Game) copyShareCode() {
+	g.clipboard.Write(g.shareCode)
+	g.showFeedback("Copied!")
+}
+
+// advance moves the game forward by one update: it counts the tick, plays the
+// transition between boards and the move animation, and scores a finished move.
+// It returns true while an animation is playing, during which selections are
+// only queued, or if a queued selection started the next move.
+func (g *Game) advance() (busy bool) {
+	// Advance the clock. Time is measured in ticks, so it runs at game speed.
+	if !g.isGameOver {
+		g.ticks++
+		if g.mode == ModeTimeAttack {
+			g.timer.Tick()
+		}
+	}
+
+	// Let the transition to the next zen board play out before accepting input
+	if g.transition != nil {
+		if g.transition.Update(g.clock.Now()) {
+			g.transition = nil
+		}
+		return true
+	}
+
 	// Handle board animation
 	if g.board.IsAnimating {
 		if g.board.UpdateAnimation() {
-			// Animation finished, recalculate score
-			g.score = scoring.CalculateScore(g.board.Grid(), scoring.StandardRuleSet{})
-			g.scoreHistory = append(g.scoreHistory, g.score)
+			// Animation finished, rescore the two tiles that changed places
+			x1, y1, x2, y2 := g.board.AnimatingPieces()
+			g.scorer.Swap(y1, x1, y2, x2)
+			g.recordMove(g.animating, x1, y1, x2, y2)
+			g.updateScore()
+			if g.animating == moveUndone {
+				g.scoreHistory = g.scoreHistory[:len(g.scoreHistory)-1]
+			} else {
+				g.scoreHistory = append(g.scoreHistory, g.totalScore())
+			}
+			g.animating = movePlayed
+			g.checkGameOver()
+
+			// In zen mode, a completed board flows into the next one
+			if g.mode == ModeZen && g.score >= g.zenTarget {
+				g.nextZenBoard()
+			}
 		}
-		return nil
+		return true
+	}
+
+	// A move that is still animating when the time expires is allowed to finish.
+	g.checkGameOver()
+
+	// A move may not cost more than what is left of the budget.
+	if g.budget > 0 {
+		g.board.SetMaxMoveCost(g.budget - g.moveCost)
 	}
+	return g.playQueuedSelections()
+}
 
-	// Handle mouse input for piece selection/swapping
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		x, y := ebiten.CursorPosition()
+// queueSelection keeps a cell that was selected, with the mouse or the
+// keyboard, while a move was animating. Selections beyond
+// config.InputQueueLength are dropped.
+func (g *Game) queueSelection(i, j int) {
+	if len(g.queuedCells) < config.InputQueueLength {
+		g.queuedCells = append(g.queuedCells, image.Point{X: i, Y: j})
+	}
+}
 
-		// Check if the share code was clicked
-		if view.IsShareCodeClicked(x, y, g.shareCode) {
-			clipboard.Write(clipboard.FmtText, []byte(g.shareCode))
-			g.copyFeedback = "Copied!"
-			g.copyFeedbackTime = time.Now()
-		} else if g.board.HandleInput(x, y) {
-			// A move was made
-			g.moveCount++
-			g.audioManager.PlayMoveSound(g.board.AnimationDuration())
+// playQueuedSelections passes the queued selections to the board in the order
+// they were made, until one of them makes a move. The remaining ones wait for
+// that move to finish, so every queued swap is played, counted and scored
+// exactly as if the player had waited. It returns true if a move was made.
+func (g *Game) playQueuedSelections() bool {
+	if g.isGameOver {
+		g.queuedCells = nil
+		return false
+	}
+	for len(g.queuedCells) > 0 {
+		cell := g.queuedCells[0]
+		g.queuedCells = g.queuedCells[1:]
+		if g.selectCell(cell.X, cell.Y) {
+			return true
 		}
 	}
+	return false
+}
 
-	// Reset copy feedback message after a delay
-	if g.copyFeedback != "" && time.Since(g.copyFeedbackTime).Seconds() > 1.5 {
-		g.copyFeedback = ""
+// busyClick handles a click or tap at (x, y) while a move or the transition
+// between zen boards plays. Selections on the board are queued during a move
+// and dropped during a transition, whose board is being replaced. The share
+// code and the color distribution take the click right away.
+func (g *Game) busyClick(x, y int) {
+	if i, j, ok := board.CellAt(g.layout, x, y); ok {
+		if g.transition == nil {
+			g.queueSelection(i, j)
+		}
+		return
+	}
+	if !g.isGameOver {
+		g.click(x, y)
 	}
+}
 
-	return nil
+// click handles a click of the left mouse button at the given screen coordinates.
+func (g *Game) click(x, y int) {
+	// Check if the share code was clicked
+	if g.screen.IsShareCodeClicked(g.layout, x, y, g.shareCode) {
+		g.copyShareCode()
+	} else if g.isGameOver {
+		// Any click on the results screen starts the next board
+		g.startNewGame(nil)
+	} else if c := g.screen.DistributionColorAt(g.layout, g.breakdown, g.shapes, x, y); c != nil {
+		// Clicking a miniature pins the highlight of its color, clicking it again releases it
+		if c == g.pinnedColor {
+			g.pinnedColor = nil
+		} else {
+			g.pinnedColor = c
+		}
+	} else if i, j, ok := board.CellAt(g.layout, x, y); ok {
+		// The mouse is used, so the keyboard cursor steps aside. It continues from the clicked cell.
+		g.cursorX, g.cursorY, g.cursorVisible = i, j, false
+		g.selectCell(i, j)
+	}
 }
 
-// Draw renders the game screen.
-func (g *Game) Draw(screen *ebiten.Image) {
-	mouseX, mouseY := ebiten.CursorPosition()
-	view.Draw(screen, g.board, g.score, g.maxScore, g.moveCount, g.scoreHistory, g.colorCounts, mouseX, mouseY)
+// selectCell selects the cell (i, j) on the board, swapping it with the
+// selected tile if there is one. It returns true if a move was made.
+func (g *Game) selectCell(i, j int) bool {
+	if !g.board.SelectCell(i, j) {
+		return false
+	}
+	g.moveStarted()
+	return true
+}
+
+// swap swaps the tiles at (x1, y1) and (x2, y2) directly. It returns true if
+// a move was made.
+func (g *Game) swap(x1, y1, x2, y2 int) bool {
+	if !g.board.Swap(x1, y1, x2, y2) {
+		return false
+	}
+	g.moveStarted()
+	return true
+}
+
+// moveStarted accounts for a move that has just started on the board; its
+// cost depends on the swap mode.
+func (g *Game) moveStarted() {
+	g.preview = nil
+	g.moveCost += g.board.MoveCost()
+	g.sound.PlayMoveSound(g.board.AnimationDuration())
+}
+
+// dragGesture is a tile that was selected by pressing the mouse button on it,
+// or touched with a finger. Once the mouse or the finger moves far enough, the
+// tile is dragged, and releasing it over another tile swaps the two.
+type dragGesture struct {
+	i, j           int  // Grid position of the pressed tile
+	startX, startY int  // Screen position where the button was pressed
+	active         bool // Whether the tile is dragged, rather than just clicked
+}
+
+// startDrag starts a drag gesture if the click at (x, y) selected a tile.
+// Clicks that swap, deselect or miss the board don't start one, so
+// click-to-select keeps working as before.
+func (g *Game) startDrag(x, y int) {
+	i, j, ok := board.CellAt(g.layout, x, y)
+	if selectedX, selectedY := g.board.Selected(); !ok || g.isGameOver || i != selectedX || j != selectedY {
+		return
+	}
+	g.drag = &dragGesture{i: i, j: j, startX: x, startY: y}
+}
+
+// updateDrag follows the mouse with the pressed tile and swaps it when the
+// button is released over a tile it may be swapped with. A tile that is
+// released elsewhere stays selected, just as after a click.
+func (g *Game) updateDrag(x, y int, pressed bool) {
+	if g.drag == nil {
+		return
+	}
+	if !pressed {
+		g.drop(x, y)
+		return
+	}
+	if movedFurther(g.drag.startX, g.drag.startY, x, y, config.DragThreshold) {
+		g.drag.active = true
+	}
+}
+
+// drop ends the drag gesture at the screen position (x, y), swapping the
+// dragged tile with the tile there if the two may be swapped.
+func (g *Game) drop(x, y int) {
+	if x1, y1, x2, y2, ok := g.dropTarget(x, y); ok {
+		g.swap(x1, y1, x2, y2)
+	}
+	g.drag = nil
+}
+
+// dropTarget returns the swap that dropping the dragged tile at the screen
+// position (x, y) would make. ok is false if no tile is dragged or the tile
+// there may not be swapped with it.
+func (g *Game) dropTarget(x, y int) (x1, y1, x2, y2 int, ok bool) {
+	i, j, onCell := board.CellAt(g.layout, x, y)
+	if g.drag == nil || !g.drag.active || !onCell || !g.board.CanSwap(g.drag.i, g.drag.j, i, j) {
+		return 0, 0, 0, 0, false
+	}
+	return g.drag.i, g.drag.j, i, j, true
+}
 
-	// Draw the new sharing UI elements
-	view.DrawSharingUI(screen, g.shareCode, g.copyFeedback, g.isCustomBoard)
+// movedFurther reports whether (x, y) is more than distance pixels away from (startX, startY).
+func movedFurther(startX, startY, x, y, distance int) bool {
+	dx, dy := x-startX, y-startY
+	return dx*dx+dy*dy > distance*distance
 }
 
-// Layout is called when the window is resized.
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return config.ScreenWidth, config.ScreenHeight
+// dragView returns the dragged tile for drawing, or nil if no tile is dragged.
+func (g *Game) dragView() *scene.Drag {
+	if g.drag == nil || !g.drag.active {
+		return nil
+	}
+	d := g.drag
+	x, y := g.layout.Cell(d.i, d.j)
+	return &scene.Drag{I: d.i, J: d.j, GrabX: d.startX - x, GrabY: d.startY - y}
+}
+
+// cursorView returns the board cursor for drawing, or nil while it is hidden.
+func (g *Game) cursorView() *scene.Cursor {
+	if !g.cursorVisible || g.isGameOver {
+		return nil
+	}
+	return &scene.Cursor{I: g.cursorX, J: g.cursorY}
+}
+
+// SetLayout arranges the screen for a new window size, see layout.Fit.
+func (g *Game) SetLayout(l layout.Layout) {
+	g.layout = l
+}
+
+// Frame returns what the screen shows, for drawing it.
+func (g *Game) Frame() scene.Frame {
+	mouseX, mouseY := g.pointer()
+	status := scene.Status{
+		Score:    g.totalScore(),
+		MaxScore: g.session.Score + g.maxScore,
+		MoveCost: g.moveCost,
+		Budget:   g.budget,
+		TimeLeft: -1,
+		Boards:   g.session.Boards,
+	}
+	if g.mode == ModeZen {
+		status.MaxScore = g.session.Score + g.zenTarget // The score at which the board flows into the next one
+	}
+	if g.mode == ModeTimeAttack {
+		status.TimeLeft = g.timer.SecondsLeft()
+	}
+	// Hovering a miniature highlights its color, otherwise the pinned color is highlighted.
+	highlight := g.pinnedColor
+	if c := g.screen.DistributionColorAt(g.layout, g.breakdown, g.shapes, mouseX, mouseY); c != nil {
+		highlight = c
+	}
+	f := scene.Frame{
+		Layout:        g.layout,
+		Board:         g.board,
+		Status:        status,
+		ScoreHistory:  g.scoreHistory,
+		Breakdown:     g.breakdown,
+		Shapes:        g.shapes,
+		Highlight:     highlight,
+		Outlines:      g.outlines(),
+		Preview:       g.preview,
+		Drag:          g.dragView(),
+		Cursor:        g.cursorView(),
+		Transition:    g.transition,
+		MouseX:        mouseX,
+		MouseY:        mouseY,
+		ShareCode:     g.shareCode,
+		Feedback:      g.copyFeedback,
+		IsCustomBoard: g.isCustomBoard,
+	}
+	if g.isGameOver {
+		f.Results = &scene.Results{Title: g.resultTitle(), Lines: g.resultLines()}
+	}
+	if g.isSettingsOpen {
+		f.Settings = &scene.Menu{Title: "Settings", Options: g.settingsOptions(), Focus: g.settingsFocus}
+	}
+	return f
+}
+
+// resultTitle returns the headline of the results screen.
+func (g *Game) resultTitle() string {
+	switch {
+	case g.score >= g.maxScore:
+		return "Board solved"
+	case g.mode == ModeTimeAttack:
+		return "Time's up"
+	default:
+		return "Out of moves"
+	}
+}
+
+// resultLines returns the details shown on the results screen.
+func (g *Game) resultLines() []string {
+	lines := []string{fmt.Sprintf("Score: %d / %d", g.score, g.maxScore)}
+	if g.budget > 0 {
+		lines = append(lines, fmt.Sprintf("Moves: %d / %d", g.moveCost, g.budget))
+	} else {
+		lines = append(lines, fmt.Sprintf("Moves: %d", g.moveCost))
+	}
+	if g.mode == ModeTimeAttack {
+		elapsed := g.ticks / config.TPS
+		lines = append(lines, fmt.Sprintf("Time: %s", scene.FormatTime(elapsed)))
+	}
+	lines = append(lines, fmt.Sprintf("Grade: %s", grade(g.score, g.maxScore)))
+
+	modeStats := g.stats.Mode(g.mode.String())
+	if g.isNewBest {
+		lines = append(lines, fmt.Sprintf("New best: %d%%", modeStats.BestPercent))
+	} else {
+		lines = append(lines, fmt.Sprintf("Best: %d%% (%d games)", modeStats.BestPercent, modeStats.Played))
+	}
+	return append(lines, "Click to play the next board")
 }
@@ -0,0 +1,574 @@
+package game
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+	"time"
+	"zenmojo/board"
+	"zenmojo/clock"
+	"zenmojo/config"
+	"zenmojo/layout"
+	"zenmojo/scoring"
+	"zenmojo/sharing"
+	"zenmojo/stats"
+	"zenmojo/tiles"
+)
+
+// blockGrid returns a solved board of ten 2x5 blocks, with the tiles at
+// (0, 1) and (0, 2) swapped so that a single move solves it again.
+func blockGrid() tiles.Grid {
+	grid := tiles.New(config.GridSize, config.GridSize)
+	for r := 0; r < grid.Height; r++ {
+		for c := 0; c < grid.Width; c++ {
+			grid.Set(r, c, tiles.CellOf(c/2+5*(r/5)))
+		}
+	}
+	grid.Swap(0, 1, 0, 2)
+	return grid
+}
+
+// fakePlatform stands in for the computer the game runs on: no key or button
+// is pressed, nothing is drawn or played, and the clipboard and the window
+// only keep what they are given. The share code covers shareCodeArea.
+type fakePlatform struct {
+	clipboard     string
+	fullscreen    bool
+	shareCodeArea image.Rectangle
+}
+
+func (*fakePlatform) KeyPressDuration(Key) int                                { return 0 }
+func (*fakePlatform) MousePressDuration() int                                 { return 0 }
+func (*fakePlatform) CursorPosition() (int, int)                              { return 0, 0 }
+func (*fakePlatform) JustPressedTouchIDs() []TouchID                          { return nil }
+func (*fakePlatform) IsTouchJustReleased(TouchID) bool                        { return false }
+func (*fakePlatform) TouchPosition(TouchID) (int, int)                        { return 0, 0 }
+func (*fakePlatform) JustConnectedGamepadIDs() []GamepadID                    { return nil }
+func (*fakePlatform) IsGamepadJustDisconnected(GamepadID) bool                { return false }
+func (*fakePlatform) GamepadName(GamepadID) string                            { return "" }
+func (*fakePlatform) IsStandardGamepad(GamepadID) bool                        { return false }
+func (*fakePlatform) GamepadButtonPressDuration(GamepadID, GamepadButton) int { return 0 }
+func (*fakePlatform) LeftStick(GamepadID) (float64, float64)                  { return 0, 0 }
+func (*fakePlatform) SettingsOptionAt(layout.Layout, int, int, int) int       { return -1 }
+func (*fakePlatform) DistributionColorAt(layout.Layout, map[color.Color]scoring.ColorBreakdown, map[tiles.Cell]scoring.Shape, int, int) color.Color {
+	return nil
+}
+func (p *fakePlatform) IsShareCodeClicked(_ layout.Layout, x, y int, _ string) bool {
+	return image.Pt(x, y).In(p.shareCodeArea)
+}
+func (p *fakePlatform) Read() string                  { return p.clipboard }
+func (p *fakePlatform) Write(text string)             { p.clipboard = text }
+func (*fakePlatform) PlayMoveSound(float64)           {}
+func (p *fakePlatform) IsFullscreen() bool            { return p.fullscreen }
+func (p *fakePlatform) SetFullscreen(fullscreen bool) { p.fullscreen = fullscreen }
+func (*fakePlatform) SetIcon([]image.Image)           {}
+
+// newTestGame starts a game on the given cells on a fake platform, without
+// saved statistics or house rules, driven by a fake clock.
+func newTestGame(mode Mode, cells tiles.Grid) (*Game, *clock.Fake) {
+	fake := clock.NewFake()
+	p := &fakePlatform{}
+	g := &Game{
+		input:     p,
+		screen:    p,
+		clipboard: p,
+		sound:     p,
+		window:    p,
+		clock:     fake,
+		layout:    testLayout,
+		easing:    config.SwapEasing,
+		mode:      mode,
+		ruleSetID: scoring.DefaultRuleSetID,
+		stats:     stats.New(),
+	}
+	g.startNewGame(&cells)
+	return g, fake
+}
+
+// testLayout is the layout of the smallest portrait canvas, on which the test games are played.
+var testLayout = layout.New(config.ScreenWidth, config.ScreenHeight, false)
+
+// cellCenter returns the screen coordinates of the center of the cell in column i and row j.
+func cellCenter(i, j int) (int, int) {
+	x, y := testLayout.Cell(i, j)
+	return x + testLayout.Square/2, y + testLayout.Square/2
+}
+
+// seconds converts a duration in seconds to a time.Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func TestMoveAnimatesAndScores(t *testing.T) {
+	g, fake := newTestGame(ModeClassic, blockGrid())
+	if g.score != 800 || g.maxScore != 1000 {
+		t.Fatalf("Expected a score of 800 out of 1000, got %d out of %d", g.score, g.maxScore)
+	}
+
+	scorer := g.scorer
+	g.click(cellCenter(1, 0))
+	g.click(cellCenter(2, 0))
+	if !g.board.IsAnimating {
+		t.Fatal("Expected the swap to start an animation")
+	}
+	if g.moveCost != 1 {
+		t.Errorf("Expected the move to cost 1, got %d", g.moveCost)
+	}
+
+	// Halfway through the animation the move is not scored yet.
+	duration := g.board.AnimationDuration()
+	fake.Advance(seconds(duration / 2))
+	if !g.advance() {
+		t.Fatal("Expected the game to be busy while the animation plays")
+	}
+	if g.score != 800 || !g.board.IsAnimating {
+		t.Errorf("Expected the move to be scored only after the animation, got %d", g.score)
+	}
+
+	fake.Advance(seconds(duration/2) + time.Millisecond)
+	g.advance()
+	if g.board.IsAnimating {
+		t.Fatal("Expected the animation to finish after its duration")
+	}
+	if g.score != 1000 {
+		t.Errorf("Expected the solved board to score 1000, got %d", g.score)
+	}
+	if g.scorer != scorer {
+		t.Error("Expected the move to be scored by the scorer of the board")
+	}
+	if expected := scoring.Breakdown(g.board.Cells(), g.board.Palette(), g.rules()); !reflect.DeepEqual(g.breakdown, expected) {
+		t.Errorf("Expected the breakdown %v of the solved board, got %v", expected, g.breakdown)
+	}
+	if len(g.scoreHistory) != 2 || g.scoreHistory[1] != 1000 {
+		t.Errorf("Expected the score history [800 1000], got %v", g.scoreHistory)
+	}
+	if g.advance() {
+		t.Error("Expected the game to accept input again")
+	}
+}
+
+func TestZenBoardFlowsIntoNext(t *testing.T) {
+	g, fake := newTestGame(ModeZen, blockGrid())
+	if g.zenTarget != 1000 {
+		t.Fatalf("Expected the packable board to be complete at its maximum score 1000, got %d", g.zenTarget)
+	}
+	g.click(cellCenter(1, 0))
+	g.click(cellCenter(2, 0))
+	fake.Advance(seconds(g.board.AnimationDuration()) + time.Millisecond)
+	g.advance()
+
+	if g.transition == nil {
+		t.Fatal("Expected the solved board to dissolve into the next one")
+	}
+	if g.session.Boards != 1 || g.session.Score != 1000 {
+		t.Errorf("Expected one completed board worth 1000, got %d boards worth %d", g.session.Boards, g.session.Score)
+	}
+	if played := g.stats.Mode("Zen").Played; played != 0 {
+		t.Errorf("Expected the session to be recorded only when it ends, got %d games", played)
+	}
+
+	// The transition blocks input until it has played out.
+	fake.Advance(seconds(config.ZenTransitionDuration / 2))
+	if !g.advance() || g.transition == nil {
+		t.Error("Expected the transition to be still playing")
+	}
+	fake.Advance(seconds(config.ZenTransitionDuration/2) + time.Millisecond)
+	g.advance()
+	if g.transition != nil {
+		t.Error("Expected the transition to end after its duration")
+	}
+
+	// A new game ends the session, which counts as a single game.
+	g.startNewGame(nil)
+	if m := g.stats.Mode("Zen"); m.Played != 1 || m.TotalScore != 1000 {
+		t.Errorf("Expected the session to be recorded as one game of 1000 points, got %+v", m)
+	}
+	if g.session.Boards != 0 {
+		t.Errorf("Expected a new session, got %d boards", g.session.Boards)
+	}
+}
+
+func TestTapDuringZenTransition(t *testing.T) {
+	g, fake := newTestGame(ModeZen, blockGrid())
+	g.click(cellCenter(1, 0))
+	g.click(cellCenter(2, 0))
+	fake.Advance(seconds(g.board.AnimationDuration()) + time.Millisecond)
+	g.advance()
+	if g.transition == nil {
+		t.Fatal("Expected the solved board to dissolve into the next one")
+	}
+
+	// The share code takes a tap right away, while the board doesn't.
+	p := g.screen.(*fakePlatform)
+	p.shareCodeArea = image.Rect(0, 0, 10, 10)
+	g.tap(5, 5)
+	if p.clipboard != g.shareCode {
+		t.Errorf("Expected the tap to copy the share code %q, got %q", g.shareCode, p.clipboard)
+	}
+	g.tap(cellCenter(0, 0))
+	if x, y := g.board.Selected(); x != -1 || y != -1 || len(g.queuedCells) != 0 {
+		t.Error("Expected a tap on the board to be dropped during the transition")
+	}
+}
+
+// TestZenBoardFlowsAtReachableTarget checks that a zen board flows into the
+// next one at the solver's target score, even if the maximum score is higher.
+func TestZenBoardFlowsAtReachableTarget(t *testing.T) {
+	g, fake := newTestGame(ModeZen, blockGrid())
+	g.maxScore = 2000 // As if the best shapes didn't fit together on the board
+	g.click(cellCenter(1, 0))
+	g.click(cellCenter(2, 0))
+	fake.Advance(seconds(g.board.AnimationDuration()) + time.Millisecond)
+	g.advance()
+
+	if g.transition == nil || g.session.Boards != 1 {
+		t.Error("Expected the board to flow into the next one at the target score")
+	}
+}
+
+// TestZenTargetOfUnpackableBoard checks that a zen board whose best shapes the
+// solver can't pack doesn't count as complete long before its maximum score.
+func TestZenTargetOfUnpackableBoard(t *testing.T) {
+	rows := []string{
+		"LFAFMUMODP",
+		"THSECHJETS",
+		"NFKNNNRSNM",
+		"PAJKMTSIAL",
+		"TNELUMKONN",
+		"IDTKFEEFSD",
+		"FHJLAPTTRR",
+		"HHLIUEUASF",
+		"ORFHJRESCA",
+		"MSPNKKMHM.",
+	}
+	grid := tiles.New(config.GridSize, config.GridSize)
+	for r, row := range rows {
+		for c, ch := range row {
+			if ch != '.' {
+				grid.Set(r, c, tiles.CellOf(int(ch-'A')))
+			}
+		}
+	}
+
+	g, _ := newTestGame(ModeZen, grid)
+	if g.zenTarget < g.maxScore*3/4 || g.score >= g.zenTarget {
+		t.Errorf("Expected the board to be complete near its maximum score %d, not at %d with a score of %d", g.maxScore, g.zenTarget, g.score)
+	}
+}
+
+// TestQueuedSelectionsPlayLikeWaiting queues a swap while another one animates and
+// checks that it is played, counted and scored as if the player had waited.
+func TestQueuedSelectionsPlayLikeWaiting(t *testing.T) {
+	finish := func(g *Game, fake *clock.Fake) {
+		fake.Advance(seconds(g.board.AnimationDuration()) + time.Millisecond)
+		g.advance()
+	}
+
+	waited, waitedClock := newTestGame(ModeClassic, blockGrid())
+	waited.click(cellCenter(0, 5))
+	waited.click(cellCenter(9, 5))
+	finish(waited, waitedClock)
+	waited.advance()
+	waited.click(cellCenter(1, 0))
+	waited.click(cellCenter(2, 0))
+	finish(waited, waitedClock)
+
+	queued, queuedClock := newTestGame(ModeClassic, blockGrid())
+	queued.click(cellCenter(0, 5))
+	queued.click(cellCenter(9, 5))
+	queued.queueSelection(1, 0)
+	queued.queueSelection(2, 0)
+	finish(queued, queuedClock)
+	if !queued.advance() || !queued.board.IsAnimating {
+		t.Fatal("Expected the queued swap to start once the first move has finished")
+	}
+	if len(queued.queuedCells) != 0 {
+		t.Errorf("Expected all queued selections to be played, %d are left", len(queued.queuedCells))
+	}
+	finish(queued, queuedClock)
+
+	if queued.moveCost != 2 || queued.moveCost != waited.moveCost {
+		t.Errorf("Expected both moves to count, got %d, expected %d", queued.moveCost, waited.moveCost)
+	}
+	if !reflect.DeepEqual(queued.board.Grid(), waited.board.Grid()) {
+		t.Error("Expected the queued swap to leave the same board as waiting for the move")
+	}
+	if !reflect.DeepEqual(queued.scoreHistory, waited.scoreHistory) {
+		t.Errorf("Expected the score history %v, got %v", waited.scoreHistory, queued.scoreHistory)
+	}
+	moves := []Move{{X1: 0, Y1: 5, X2: 9, Y2: 5}, {X1: 1, Y1: 0, X2: 2, Y2: 0}}
+	if replay := queued.Replay(); !reflect.DeepEqual(replay.Moves, moves) {
+		t.Errorf("Expected the moves %v in the replay, got %v", moves, replay.Moves)
+	}
+	if !reflect.DeepEqual(queued.Replay(), waited.Replay()) {
+		t.Errorf("Expected the replay %+v, got %+v", waited.Replay(), queued.Replay())
+	}
+}
+
+func TestUndoAndRedo(t *testing.T) {
+	g, fake := newTestGame(ModeClassic, blockGrid())
+	finish := func() {
+		fake.Advance(seconds(g.board.AnimationDuration()) + time.Millisecond)
+		g.advance()
+	}
+	start := g.board.Grid()
+	g.click(cellCenter(1, 0))
+	g.click(cellCenter(2, 0))
+	finish()
+	solved := g.board.Grid()
+
+	g.performMenuAction(actionUndo)
+	if !g.board.IsAnimating {
+		t.Fatal("Expected undo to swap the tiles back")
+	}
+	finish()
+	if !reflect.DeepEqual(g.board.Grid(), start) || g.score != 800 || g.moveCost != 0 {
+		t.Errorf("Expected undo to restore the board scoring 800 without cost, got %d with cost %d", g.score, g.moveCost)
+	}
+	if len(g.Replay().Moves) != 0 || !reflect.DeepEqual(g.scoreHistory, []int{800}) {
+		t.Errorf("Expected undo to take the move out of the replay and the score history, got %v and %v", g.Replay().Moves, g.scoreHistory)
+	}
+	g.performMenuAction(actionUndo)
+	if g.board.IsAnimating {
+		t.Error("Expected nothing to undo at the start of the board")
+	}
+
+	g.performMenuAction(actionRedo)
+	finish()
+	if !reflect.DeepEqual(g.board.Grid(), solved) || g.score != 1000 || g.moveCost != 1 {
+		t.Errorf("Expected redo to make the move again, got %d with cost %d", g.score, g.moveCost)
+	}
+	if moves := []Move{{X1: 1, Y1: 0, X2: 2, Y2: 0}}; !reflect.DeepEqual(g.Replay().Moves, moves) {
+		t.Errorf("Expected the replay %v after redo, got %v", moves, g.Replay().Moves)
+	}
+
+	// A new move drops the moves that could be redone.
+	g.performMenuAction(actionUndo)
+	finish()
+	g.click(cellCenter(0, 5))
+	g.click(cellCenter(9, 5))
+	finish()
+	g.performMenuAction(actionRedo)
+	if g.board.IsAnimating || len(g.undone) != 0 {
+		t.Error("Expected nothing to redo after a new move")
+	}
+}
+
+func TestNoUndoInChallenge(t *testing.T) {
+	g, fake := newTestGame(ModeChallenge, blockGrid())
+	g.click(cellCenter(0, 5))
+	g.click(cellCenter(9, 5))
+	fake.Advance(seconds(g.board.AnimationDuration()) + time.Millisecond)
+	g.advance()
+	if g.isGameOver {
+		t.Fatal("Expected the challenge to go on after the move")
+	}
+	played := g.board.Grid()
+
+	g.performMenuAction(actionUndo)
+	if g.board.IsAnimating || !reflect.DeepEqual(g.board.Grid(), played) {
+		t.Error("Expected no undo in a challenge")
+	}
+	if g.moveCost != 1 || len(g.Replay().Moves) != 1 {
+		t.Errorf("Expected the move to stay spent, got cost %d and moves %v", g.moveCost, g.Replay().Moves)
+	}
+}
+
+func TestReplayRecordsRules(t *testing.T) {
+	g, _ := newTestGame(ModeClassic, blockGrid())
+	if replay := g.Replay(); replay.RuleSetID != scoring.DefaultRuleSetID || replay.ShareCode != g.shareCode {
+		t.Errorf("Expected the replay of the board under the default rules, got %+v", replay)
+	}
+
+	g.cycleRules()
+	if replay := g.Replay(); replay.RuleSetID != g.ruleSetID || replay.RuleSetID == scoring.DefaultRuleSetID {
+		t.Errorf("Expected the replay to record the rules %q, got %q", g.ruleSetID, replay.RuleSetID)
+	}
+}
+
+func TestQueuedSelectionsAreDroppedWithTheBoard(t *testing.T) {
+	g, fake := newTestGame(ModeTimeAttack, blockGrid())
+	g.click(cellCenter(1, 0))
+	g.click(cellCenter(2, 0))
+	g.queueSelection(0, 5)
+	g.queueSelection(9, 5)
+
+	// The move solves the board, so the game is over and the queued swap is not played.
+	fake.Advance(seconds(g.board.AnimationDuration()) + time.Millisecond)
+	g.advance()
+	if g.advance() || g.board.IsAnimating || len(g.queuedCells) != 0 {
+		t.Error("Expected the queued selections to be dropped when the game is over")
+	}
+	if g.moveCost != 1 {
+		t.Errorf("Expected only the first move to count, got %d", g.moveCost)
+	}
+
+	for i := 0; i < config.InputQueueLength+2; i++ {
+		g.queueSelection(i, 0)
+	}
+	if len(g.queuedCells) != config.InputQueueLength {
+		t.Errorf("Expected at most %d queued selections, got %d", config.InputQueueLength, len(g.queuedCells))
+	}
+}
+
+func TestDragSwapsOnRelease(t *testing.T) {
+	g, _ := newTestGame(ModeClassic, blockGrid())
+	x, y := cellCenter(1, 0)
+	g.click(x, y)
+	g.startDrag(x, y)
+	if g.drag == nil {
+		t.Fatal("Expected pressing a tile to start a drag")
+	}
+
+	// Small movements of the mouse still count as a click.
+	g.updateDrag(x+2, y+2, true)
+	if g.dragView() != nil {
+		t.Error("Expected the tile not to be dragged before the mouse has moved far enough")
+	}
+
+	targetX, targetY := cellCenter(2, 0)
+	g.updateDrag(targetX, targetY, true)
+	if d := g.dragView(); d == nil || d.I != 1 || d.J != 0 || d.GrabX != testLayout.Square/2 {
+		t.Fatalf("Expected the tile at (1, 0) to be dragged by its center, got %+v", d)
+	}
+	g.updatePreview(g.board.SwapAt(g.layout, targetX, targetY))
+	if g.preview == nil || g.preview.X2 != 2 || g.preview.Y2 != 0 {
+		t.Errorf("Expected the tile under the cursor to be the drop target, got %+v", g.preview)
+	}
+
+	g.updateDrag(targetX, targetY, false)
+	if !g.board.IsAnimating || g.moveCost != 1 {
+		t.Errorf("Expected dropping the tile to swap it as one move, got animating %v and cost %d", g.board.IsAnimating, g.moveCost)
+	}
+	if g.drag != nil {
+		t.Error("Expected the drag to end with the release")
+	}
+}
+
+func TestDragReleasedOffTargetKeepsSelection(t *testing.T) {
+	g, _ := newTestGame(ModeClassic, blockGrid())
+	x, y := cellCenter(1, 0)
+	g.click(x, y)
+	g.startDrag(x, y)
+	g.updateDrag(x+testLayout.Square, testLayout.Board.Min.Y-1, true)
+	g.updateDrag(x+testLayout.Square, testLayout.Board.Min.Y-1, false)
+
+	if g.board.IsAnimating {
+		t.Error("Expected no swap when the tile is dropped off the board")
+	}
+	if i, j := g.board.Selected(); i != 1 || j != 0 {
+		t.Errorf("Expected the dragged tile to stay selected, got (%d, %d)", i, j)
+	}
+
+	// The selected tile can still be swapped with a click.
+	g.click(cellCenter(2, 0))
+	if !g.board.IsAnimating {
+		t.Error("Expected a click to swap with the selected tile")
+	}
+}
+
+func TestFullscreenBoardIsPlayable(t *testing.T) {
+	g, _ := newTestGame(ModeClassic, blockGrid())
+	g.toggleFullscreen()
+	if !g.window.IsFullscreen() {
+		t.Fatal("Expected the game to switch to fullscreen")
+	}
+
+	// The app fits the layout to the monitor once the window is fullscreen.
+	g.SetLayout(layout.Fit(2560, 1440))
+	if g.layout.Square < 2*config.SquareSize {
+		t.Fatalf("Expected the tiles to grow with the monitor, got %dpx", g.layout.Square)
+	}
+	x, y := g.layout.Cell(3, 2)
+	g.click(x+g.layout.Square-1, y+g.layout.Square-1) // Bottom right corner, outside of a 48px tile
+	if i, j := g.board.Selected(); i != 3 || j != 2 {
+		t.Errorf("Expected a click on the large tile to select it, got (%d, %d)", i, j)
+	}
+}
+
+func TestCheckSwapMode(t *testing.T) {
+	sliding := blockGrid()
+	sliding.Set(9, 9, tiles.Empty)
+
+	// Three distinct tiles whose parity doesn't match the way of the gap to
+	// the bottom right corner can't be slid into place.
+	unsolvable := tiles.New(2, 2)
+	copy(unsolvable.Cells, []tiles.Cell{tiles.CellOf(0), tiles.Empty, tiles.CellOf(1), tiles.CellOf(2)})
+
+	testCases := []struct {
+		name    string
+		grid    tiles.Grid
+		mode    board.SwapMode
+		wantErr bool
+	}{
+		{"free swaps", blockGrid(), board.SwapAny, false},
+		{"sliding puzzle", sliding, board.SwapSlide, false},
+		{"sliding puzzle without empty cell", blockGrid(), board.SwapSlide, true},
+		{"unsolvable sliding puzzle", unsolvable, board.SwapSlide, true},
+		{"free swaps with empty cell", sliding, board.SwapAny, true},
+		{"adjacent swaps with empty cell", sliding, board.SwapAdjacent, true},
+		{"unknown mode", blockGrid(), board.SwapSlide + 1, true},
+		{"negative mode", blockGrid(), -1, true},
+	}
+	for _, tc := range testCases {
+		if err := checkSwapMode(tc.grid, tc.mode); (err != nil) != tc.wantErr {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+}
+
+func TestPasteRejectsUnplayableCodes(t *testing.T) {
+	sliding := blockGrid()
+	sliding.Set(9, 9, tiles.Empty)
+
+	testCases := []struct {
+		name  string
+		mode  Mode
+		cells tiles.Grid
+		opts  sharing.Options
+	}{
+		{"empty cell without sliding", ModeClassic, sliding, sharing.Options{SwapMode: int(board.SwapAny)}},
+		{"sliding challenge", ModeClassic, sliding, sharing.Options{SwapMode: int(board.SwapSlide), Budget: 20}},
+		{"sliding puzzle during a challenge", ModeChallenge, sliding, sharing.Options{SwapMode: int(board.SwapSlide)}},
+		{"unknown rules", ModeClassic, blockGrid(), sharing.Options{Rules: "no-such-rules"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, _ := newTestGame(tc.mode, blockGrid())
+			shareCode := g.shareCode
+			code, err := sharing.EncodeTilesWithOptions(tc.cells, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.clipboard.Write(code)
+
+			if g.paste() {
+				t.Error("Expected the code to be rejected")
+			}
+			if g.shareCode != shareCode || g.mode != tc.mode {
+				t.Error("Expected the current game to go on")
+			}
+			if g.copyFeedback == "" {
+				t.Error("Expected feedback on the rejected code")
+			}
+		})
+	}
+}
+
+func TestSlidingPuzzlesAreNoChallenges(t *testing.T) {
+	g, _ := newTestGame(ModeClassic, blockGrid())
+	g.swapMode = board.SwapSlide
+	g.cycleMode()
+	if g.mode != ModeTimeAttack {
+		t.Errorf("Expected the challenge to be skipped for sliding puzzles, got %s", g.mode)
+	}
+
+	g, _ = newTestGame(ModeChallenge, blockGrid())
+	for g.swapMode != board.SwapSlide {
+		g.cycleSwapMode()
+	}
+	if g.mode != ModeClassic || g.budget != 0 {
+		t.Errorf("Expected a classic game without a budget once the tiles slide, got %s with budget %d", g.mode, g.budget)
+	}
+}
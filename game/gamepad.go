@@ -0,0 +1,109 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"zenmojo/config"
+)
+
+// gamepadBindings maps the buttons of the standard gamepad layout onto actions.
+var gamepadBindings = []struct {
+	button GamepadButton
+	action action
+}{
+	{buttonLeftTop, actionUp},
+	{buttonLeftBottom, actionDown},
+	{buttonLeftLeft, actionLeft},
+	{buttonLeftRight, actionRight},
+	{buttonRightBottom, actionSelect},
+	{buttonRightRight, actionCancel},
+	{buttonCenterRight, actionSettings},
+	{buttonFrontTopLeft, actionUndo},
+	{buttonFrontTopRight, actionRedo},
+}
+
+// gamepads keeps track of the connected gamepads that have the standard
+// layout, and of how long their left stick has been tilted in one direction.
+// Gamepads may be connected and disconnected while the game is running.
+type gamepads struct {
+	ids    []GamepadID
+	sticks map[GamepadID]stickHold
+}
+
+// stickHold is the direction a stick is tilted in, as a cursor action, and the
+// number of updates it has been held there.
+type stickHold struct {
+	direction action
+	ticks     int
+}
+
+// update notices connected and disconnected gamepads, telling the player about
+// them with notify, and returns the actions of the buttons pressed and sticks
+// tilted in this update. Like keys, the d-pad and the stick repeat while held.
+func (p *gamepads) update(in Input, notify func(message string)) []action {
+	for _, id := range in.JustConnectedGamepadIDs() {
+		if !in.IsStandardGamepad(id) {
+			notify(fmt.Sprintf("Gamepad not supported: %s", in.GamepadName(id)))
+			continue
+		}
+		p.ids = append(p.ids, id)
+		notify(fmt.Sprintf("Gamepad connected: %s", in.GamepadName(id)))
+	}
+	p.ids = slices.DeleteFunc(p.ids, func(id GamepadID) bool {
+		if !in.IsGamepadJustDisconnected(id) {
+			return false
+		}
+		delete(p.sticks, id)
+		notify("Gamepad disconnected")
+		return true
+	})
+
+	var actions []action
+	for _, id := range p.ids {
+		for _, b := range gamepadBindings {
+			ticks := in.GamepadButtonPressDuration(id, b.button)
+			if b.action.repeats() && repeatsAt(ticks) || ticks == 1 {
+				actions = append(actions, b.action)
+			}
+		}
+
+		direction, tilted := stickDirection(in.LeftStick(id))
+		if !tilted {
+			delete(p.sticks, id)
+			continue
+		}
+		if p.sticks == nil {
+			p.sticks = make(map[GamepadID]stickHold)
+		}
+		hold := p.sticks[id]
+		if hold.direction != direction {
+			hold = stickHold{direction: direction}
+		}
+		hold.ticks++
+		p.sticks[id] = hold
+		if repeatsAt(hold.ticks) {
+			actions = append(actions, direction)
+		}
+	}
+	return actions
+}
+
+// stickDirection returns the cursor action for a stick at the given axis
+// values. tilted is false while the stick rests within config.StickDeadZone.
+// A diagonal stick moves the cursor along the axis it is tilted further on.
+func stickDirection(horizontal, vertical float64) (direction action, tilted bool) {
+	if math.Hypot(horizontal, vertical) < config.StickDeadZone {
+		return 0, false
+	}
+	switch {
+	case math.Abs(horizontal) >= math.Abs(vertical) && horizontal > 0:
+		return actionRight, true
+	case math.Abs(horizontal) >= math.Abs(vertical):
+		return actionLeft, true
+	case vertical > 0:
+		return actionDown, true
+	default:
+		return actionUp, true
+	}
+}
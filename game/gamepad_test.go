@@ -0,0 +1,82 @@
+package game
+
+import (
+	"testing"
+	"zenmojo/config"
+)
+
+func TestStickDirection(t *testing.T) {
+	for _, tc := range []struct {
+		name                 string
+		horizontal, vertical float64
+		expected             action
+		tilted               bool
+	}{
+		{"Resting", 0, 0, 0, false},
+		{"Within dead zone", 0.3, -0.3, 0, false},
+		{"Right", 1, 0, actionRight, true},
+		{"Left", -0.8, 0.1, actionLeft, true},
+		{"Down", 0.2, 0.9, actionDown, true},
+		{"Up", -0.1, -0.7, actionUp, true},
+		{"Diagonal leaning right", 0.7, -0.6, actionRight, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			direction, tilted := stickDirection(tc.horizontal, tc.vertical)
+			if tilted != tc.tilted || tilted && direction != tc.expected {
+				t.Errorf("Expected direction %d (tilted %v), got %d (tilted %v)", tc.expected, tc.tilted, direction, tilted)
+			}
+		})
+	}
+}
+
+func TestRepeatsAt(t *testing.T) {
+	var fired []int
+	for ticks := 0; ticks <= config.KeyRepeatDelay+2*config.KeyRepeatInterval; ticks++ {
+		if repeatsAt(ticks) {
+			fired = append(fired, ticks)
+		}
+	}
+	expected := []int{1, config.KeyRepeatDelay + config.KeyRepeatInterval, config.KeyRepeatDelay + 2*config.KeyRepeatInterval}
+	if len(fired) != len(expected) {
+		t.Fatalf("Expected the action at ticks %v, got %v", expected, fired)
+	}
+	for i := range expected {
+		if fired[i] != expected[i] {
+			t.Errorf("Expected the action at ticks %v, got %v", expected, fired)
+		}
+	}
+}
+
+func TestSettingsNavigation(t *testing.T) {
+	g, _ := newTestGame(ModeClassic, blockGrid())
+	g.performMenuAction(actionSettings)
+
+	// Moving up from the first entry wraps around to Close.
+	g.updateSettings([]action{actionUp})
+	if g.settingsFocus != len(g.settingsOptions())-1 {
+		t.Fatalf("Expected the focus to wrap around to the last entry, got %d", g.settingsFocus)
+	}
+	g.updateSettings([]action{actionUp, actionUp, actionUp, actionSelect})
+	if g.easing != config.SwapEasing.Next() {
+		t.Errorf("Expected the focused entry to switch the animation, got %s", g.easing)
+	}
+	g.updateSettings([]action{actionDown, actionDown, actionDown, actionSelect})
+	if g.isSettingsOpen {
+		t.Error("Expected the Close entry to close the settings menu")
+	}
+}
+
+func TestShoulderButtonsUndoAndRedo(t *testing.T) {
+	for _, tc := range []struct {
+		button GamepadButton
+		want   action
+	}{{buttonFrontTopLeft, actionUndo}, {buttonFrontTopRight, actionRedo}} {
+		found := false
+		for _, b := range gamepadBindings {
+			found = found || b.button == tc.button && b.action == tc.want
+		}
+		if !found {
+			t.Errorf("Expected button %d to be bound to action %d", tc.button, tc.want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package game
+
+import "zenmojo/config"
+
+// action is something the player wants to do, independent of the input device.
+// Keys and gamepad buttons are mapped onto actions by keyBindings and
+// gamepadBindings; the mouse selects and swaps cells directly, see Game.click
+// and dragGesture.
+type action int
+
+const (
+	actionUp         action = iota // Move the board cursor up
+	actionDown                     // Move the board cursor down
+	actionLeft                     // Move the board cursor left
+	actionRight                    // Move the board cursor right
+	actionSelect                   // Select the cell under the board cursor, see board.Board.SelectCell
+	actionCancel                   // Cancel the selection, or close the settings menu
+	actionSettings                 // Open or close the settings menu
+	actionRules                    // Switch to the next scoring rules
+	actionSwapMode                 // Switch to the next swap mode
+	actionGameMode                 // Switch to the next game mode
+	actionAnimation                // Switch to the next easing of swap animations
+	actionCopy                     // Copy the share code to the clipboard
+	actionPaste                    // Load the share code in the clipboard
+	actionFullscreen               // Switch between fullscreen and the window
+	actionNewGame                  // Start a new game on a new board
+	actionUndo                     // Take back the last move
+	actionRedo                     // Make the last move that was taken back again
+)
+
+// keyBindings maps keys onto actions. The hint of an action in the settings
+// menu shows its first key.
+var keyBindings = []struct {
+	key    Key
+	action action
+}{
+	{keyArrowUp, actionUp},
+	{keyW, actionUp},
+	{keyArrowDown, actionDown},
+	{keyS, actionDown},
+	{keyArrowLeft, actionLeft},
+	{keyA, actionLeft},
+	{keyArrowRight, actionRight},
+	{keyD, actionRight},
+	{keySpace, actionSelect},
+	{keyEnter, actionSelect},
+	{keyNumpadEnter, actionSelect},
+	{keyEscape, actionCancel},
+	{keyO, actionSettings},
+	{keyR, actionRules},
+	{keyM, actionSwapMode},
+	{keyG, actionGameMode},
+	{keyE, actionAnimation},
+	{keyC, actionCopy},
+	{keyN, actionNewGame},
+	{keyZ, actionUndo},
+	{keyY, actionRedo},
+	{keyF11, actionFullscreen},
+}
+
+// keyHint returns the name of the first key bound to the action, for showing it in menus.
+func keyHint(a action) string {
+	for _, b := range keyBindings {
+		if b.action == a {
+			if b.key == keyEscape {
+				return "Esc"
+			}
+			return string(b.key)
+		}
+	}
+	return ""
+}
+
+// keyActions returns the actions of the keys pressed in this update. Keys that
+// move the board cursor repeat while they are held down. With Control held,
+// only Ctrl-V is recognized, and with Alt held, only Alt-Enter, so that
+// shortcuts don't trigger other actions.
+func keyActions(in Input) []action {
+	justPressed := func(key Key) bool { return in.KeyPressDuration(key) == 1 }
+	if in.KeyPressDuration(keyControl) > 0 {
+		if justPressed(keyV) {
+			return []action{actionPaste}
+		}
+		return nil
+	}
+	if in.KeyPressDuration(keyAlt) > 0 {
+		if justPressed(keyEnter) || justPressed(keyNumpadEnter) {
+			return []action{actionFullscreen}
+		}
+		return nil
+	}
+	var actions []action
+	for _, b := range keyBindings {
+		if b.action.repeats() && repeatsAt(in.KeyPressDuration(b.key)) || justPressed(b.key) {
+			actions = append(actions, b.action)
+		}
+	}
+	return actions
+}
+
+// repeats reports whether holding down a key of the action repeats it.
+func (a action) repeats() bool {
+	return a == actionUp || a == actionDown || a == actionLeft || a == actionRight
+}
+
+// repeatsAt reports whether a key or button that has been held down for the
+// given number of updates triggers its action in this update: when it is
+// pressed, and then repeatedly after config.KeyRepeatDelay.
+func repeatsAt(ticks int) bool {
+	return ticks == 1 || ticks > config.KeyRepeatDelay && (ticks-config.KeyRepeatDelay)%config.KeyRepeatInterval == 0
+}
+
+// performMenuAction performs an action that changes the settings or concerns
+// the whole game rather than the board. It returns false for actions on the
+// board, see performBoardAction.
+func (g *Game) performMenuAction(a action) bool {
+	switch a {
+	case actionSettings:
+		g.isSettingsOpen = !g.isSettingsOpen
+	case actionCancel:
+		if !g.isSettingsOpen {
+			return false // Cancels the selection instead
+		}
+		g.isSettingsOpen = false
+	case actionRules:
+		g.cycleRules()
+	case actionSwapMode:
+		g.cycleSwapMode()
+	case actionGameMode:
+		g.cycleMode()
+	case actionAnimation:
+		g.cycleEasing()
+	case actionCopy:
+		g.copyShareCode()
+	case actionNewGame:
+		g.isSettingsOpen = false
+		g.startNewGame(nil)
+	case actionUndo:
+		g.undo()
+	case actionRedo:
+		g.redo()
+	default:
+		return false
+	}
+	return true
+}
+
+// performBoardAction performs an action on the board at the board cursor.
+func (g *Game) performBoardAction(a action) {
+	switch a {
+	case actionUp:
+		g.moveCursor(0, -1)
+	case actionDown:
+		g.moveCursor(0, 1)
+	case actionLeft:
+		g.moveCursor(-1, 0)
+	case actionRight:
+		g.moveCursor(1, 0)
+	case actionSelect:
+		if g.isGameOver {
+			// Like a click, the key leaves the results screen for the next board
+			g.startNewGame(nil)
+			return
+		}
+		if !g.cursorVisible {
+			g.cursorVisible = true // Show where the cursor is before selecting anything
+			return
+		}
+		g.selectCell(g.cursorX, g.cursorY)
+	case actionCancel:
+		g.board.CancelSelection()
+	}
+}
+
+// moveCursor moves the board cursor by (dx, dy) cells, staying on the board.
+// A hidden cursor is only shown at its last position, so that the first key
+// press shows where it is.
+func (g *Game) moveCursor(dx, dy int) {
+	if !g.cursorVisible {
+		g.cursorVisible = true
+		return
+	}
+	g.cursorX = min(max(g.cursorX+dx, 0), config.GridSize-1)
+	g.cursorY = min(max(g.cursorY+dy, 0), config.GridSize-1)
+}
@@ -0,0 +1,89 @@
+package game
+
+import (
+	"testing"
+	"zenmojo/config"
+)
+
+func TestKeyboardSwapsAtCursor(t *testing.T) {
+	g, _ := newTestGame(ModeClassic, blockGrid())
+
+	// The first key press only shows the cursor.
+	g.performBoardAction(actionRight)
+	if g.cursorView() == nil || g.cursorX != 0 || g.cursorY != 0 {
+		t.Fatalf("Expected the first key press to show the cursor at (0, 0), got (%d, %d)", g.cursorX, g.cursorY)
+	}
+	g.performBoardAction(actionRight)
+	g.performBoardAction(actionSelect)
+	if x, y := g.board.Selected(); x != 1 || y != 0 {
+		t.Fatalf("Expected the tile at (1, 0) to be selected, got (%d, %d)", x, y)
+	}
+
+	g.performBoardAction(actionRight)
+	g.updatePreview(g.board.SwapWith(g.cursorX, g.cursorY))
+	if g.preview == nil || g.preview.Delta != 200 {
+		t.Errorf("Expected a preview of the swap at the cursor worth 200 points, got %+v", g.preview)
+	}
+	g.performBoardAction(actionSelect)
+	if !g.board.IsAnimating || g.moveCost != 1 {
+		t.Errorf("Expected the key to swap the tiles as one move, got animating %v and cost %d", g.board.IsAnimating, g.moveCost)
+	}
+}
+
+func TestKeyboardCancelsSelection(t *testing.T) {
+	g, _ := newTestGame(ModeClassic, blockGrid())
+	g.click(cellCenter(3, 4))
+	if g.cursorView() != nil {
+		t.Error("Expected the cursor to be hidden while the mouse is used")
+	}
+	g.performBoardAction(actionCancel)
+	if x, y := g.board.Selected(); x != -1 || y != -1 {
+		t.Errorf("Expected no selection after cancelling, got (%d, %d)", x, y)
+	}
+
+	// The cursor continues from the clicked cell and stays on the board.
+	g.performBoardAction(actionDown)
+	for i := 0; i < config.GridSize; i++ {
+		g.performBoardAction(actionDown)
+	}
+	if g.cursorX != 3 || g.cursorY != config.GridSize-1 {
+		t.Errorf("Expected the cursor to stop at (3, %d), got (%d, %d)", config.GridSize-1, g.cursorX, g.cursorY)
+	}
+}
+
+func TestMenuActions(t *testing.T) {
+	g, _ := newTestGame(ModeClassic, blockGrid())
+	if g.performMenuAction(actionCancel) {
+		t.Error("Expected cancel to be left to the board while the settings menu is closed")
+	}
+	if !g.performMenuAction(actionSettings) || !g.isSettingsOpen {
+		t.Fatal("Expected the settings menu to open")
+	}
+	easing := g.easing
+	g.performMenuAction(actionAnimation)
+	if g.easing != easing.Next() || g.board.Easing() != g.easing {
+		t.Errorf("Expected the animation to switch to %s, got %s", easing.Next(), g.easing)
+	}
+	if !g.performMenuAction(actionCancel) || g.isSettingsOpen {
+		t.Error("Expected cancel to close the settings menu")
+	}
+	if g.performMenuAction(actionSelect) {
+		t.Error("Expected select to be left to the board")
+	}
+
+	shareCode := g.shareCode
+	g.performMenuAction(actionSettings)
+	if !g.performMenuAction(actionNewGame) || g.isSettingsOpen || g.isCustomBoard || g.shareCode == shareCode {
+		t.Error("Expected a new game on a new board, with the settings menu closed")
+	}
+}
+
+// TestEveryMenuActionHasAKey checks that all actions in the settings menu can
+// be reached with the keyboard.
+func TestEveryMenuActionHasAKey(t *testing.T) {
+	for _, a := range []action{actionSettings, actionCancel, actionRules, actionSwapMode, actionGameMode, actionAnimation, actionCopy, actionNewGame, actionUndo, actionRedo} {
+		if keyHint(a) == "" {
+			t.Errorf("Expected action %d to be bound to a key", a)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package game
+
+import (
+	"fmt"
+	"zenmojo/board"
+	"zenmojo/config"
+	"zenmojo/scoring"
+	"zenmojo/solver"
+	"zenmojo/tiles"
+)
+
+// Mode is the kind of game being played.
+type Mode int
+
+const (
+	// ModeClassic is played without limits until the player is satisfied.
+	ModeClassic Mode = iota
+	// ModeChallenge gives each board a move budget. The game ends when it runs out.
+	ModeChallenge
+	// ModeTimeAttack gives each board a fixed time limit. The game ends when it expires.
+	ModeTimeAttack
+	// ModeZen is an endless session without an end screen. Each completed board
+	// dissolves into a new one and the score keeps adding up.
+	ModeZen
+)
+
+// String returns a human-readable name for the game mode.
+func (m Mode) String() string {
+	switch m {
+	case ModeChallenge:
+		return "Challenge"
+	case ModeTimeAttack:
+		return "Time Attack"
+	case ModeZen:
+		return "Zen"
+	default:
+		return "Classic"
+	}
+}
+
+// Next returns the game mode following m, wrapping around after the last one.
+func (m Mode) Next() Mode {
+	return (m + 1) % (ModeZen + 1)
+}
+
+// challengeBudget returns the move budget for a challenge on the given board:
+// the solver's par for the rules and swap mode plus a safety margin. Sliding
+// puzzles are never played as challenges, because board.SwapSlide.PathCost is
+// only a rough estimate and the par could be out of reach.
+func challengeBudget(grid tiles.Grid, rules scoring.ScoringRule, swapMode board.SwapMode) int {
+	par := solver.Par(grid, rules, swapMode.PathCost)
+	margin := par * config.ChallengeMarginPercent / 100
+	if margin < config.ChallengeMinMargin {
+		margin = config.ChallengeMinMargin
+	}
+	return par + margin
+}
+
+// grade rates a final score relative to the maximum possible score.
+func grade(score, maxScore int) string {
+	if maxScore <= 0 || score >= maxScore {
+		return "Perfect"
+	}
+	switch percent := score * 100 / maxScore; {
+	case percent >= 90:
+		return "Excellent"
+	case percent >= 75:
+		return "Good"
+	case percent >= 50:
+		return "Fair"
+	default:
+		return "Keep practicing"
+	}
+}
+
+// decodeShapes looks up the required shapes of a shared target-shape puzzle
+// on the shared cells. Every color on the board needs a shape with as many
+// cells as the color has tiles, or the puzzle could not be solved. It returns
+// nil if no shapes were shared.
+func decodeShapes(ids map[tiles.Cell]int, cells tiles.Grid) (map[tiles.Cell]scoring.Shape, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	counts := make(map[tiles.Cell]int)
+	for cell, n := range cells.Counts() {
+		if n > 0 {
+			counts[tiles.Cell(cell)] = n
+		}
+	}
+
+	shapes := make(map[tiles.Cell]scoring.Shape)
+	for c, id := range ids {
+		shape, ok := scoring.ShapeFromID(id)
+		if !ok {
+			return nil, fmt.Errorf("unknown shape %d", id)
+		}
+		if shape.Size() != counts[c] {
+			return nil, fmt.Errorf("shape %q has %d cells, but its color has %d tiles", shape.Name, shape.Size(), counts[c])
+		}
+		shapes[c] = shape
+	}
+	for c := range counts {
+		if _, ok := shapes[c]; !ok {
+			return nil, fmt.Errorf("no shape for color %d", c.PaletteIndex())
+		}
+	}
+	return shapes, nil
+}
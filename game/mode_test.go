@@ -0,0 +1,65 @@
+package game
+
+import (
+	"testing"
+	"zenmojo/scoring"
+	"zenmojo/tiles"
+)
+
+func TestGrade(t *testing.T) {
+	testCases := []struct {
+		score, maxScore int
+		expected        string
+	}{
+		{100, 100, "Perfect"},
+		{95, 100, "Excellent"},
+		{80, 100, "Good"},
+		{50, 100, "Fair"},
+		{10, 100, "Keep practicing"},
+	}
+	for _, tc := range testCases {
+		if actual := grade(tc.score, tc.maxScore); actual != tc.expected {
+			t.Errorf("grade(%d, %d): expected %q, got %q", tc.score, tc.maxScore, tc.expected, actual)
+		}
+	}
+}
+
+func TestDecodeShapes(t *testing.T) {
+	grid := blockGrid() // Ten colors of ten tiles each
+	rectangle, _ := scoring.ShapeID(scoring.NewShape("Rectangle", "XXXXX", "XXXXX"))
+	line, _ := scoring.ShapeID(scoring.NewShape("Line", "XX"))
+
+	// allRectangles requires every color on the grid to form a 5x2 rectangle.
+	allRectangles := func() map[tiles.Cell]int {
+		ids := make(map[tiles.Cell]int)
+		for i := 0; i < 10; i++ {
+			ids[tiles.CellOf(i)] = rectangle
+		}
+		return ids
+	}
+
+	shapes, err := decodeShapes(allRectangles(), grid)
+	if err != nil || len(shapes) != 10 {
+		t.Fatalf("Expected ten shapes, got %d and error %v", len(shapes), err)
+	}
+
+	tooSmall := allRectangles()
+	tooSmall[tiles.CellOf(3)] = line
+	missing := allRectangles()
+	delete(missing, tiles.CellOf(3))
+	unknown := allRectangles()
+	unknown[tiles.CellOf(3)] = 9999
+	notOnBoard := allRectangles()
+	notOnBoard[tiles.CellOf(12)] = rectangle
+
+	for name, ids := range map[string]map[tiles.Cell]int{
+		"shape smaller than its color": tooSmall,
+		"color without shape":          missing,
+		"unknown shape":                unknown,
+		"color not on the board":       notOnBoard,
+	} {
+		if _, err := decodeShapes(ids, grid); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
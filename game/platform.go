@@ -0,0 +1,148 @@
+package game
+
+import (
+	"image"
+	"image/color"
+	"zenmojo/clock"
+	"zenmojo/layout"
+	"zenmojo/scoring"
+	"zenmojo/tiles"
+)
+
+// Platform connects the game to the computer it runs on. The game reads its
+// input, plays sounds and uses the clipboard and the window only through it,
+// so that it runs without a display, e.g. in tests.
+type Platform struct {
+	Input     Input
+	Screen    Screen
+	Clipboard Clipboard
+	Sound     Sound
+	Window    Window
+	Clock     clock.Clock // Times animations and effects; it should advance with the updates, see clock.Ticks
+}
+
+// Key is a key on the keyboard, named like the keys of Ebiten, e.g. "ArrowUp".
+type Key string
+
+// The keys the game is played with.
+const (
+	keyArrowUp     Key = "ArrowUp"
+	keyArrowDown   Key = "ArrowDown"
+	keyArrowLeft   Key = "ArrowLeft"
+	keyArrowRight  Key = "ArrowRight"
+	keyW           Key = "W"
+	keyA           Key = "A"
+	keyS           Key = "S"
+	keyD           Key = "D"
+	keySpace       Key = "Space"
+	keyEnter       Key = "Enter"
+	keyNumpadEnter Key = "NumpadEnter"
+	keyEscape      Key = "Escape"
+	keyO           Key = "O"
+	keyR           Key = "R"
+	keyM           Key = "M"
+	keyG           Key = "G"
+	keyE           Key = "E"
+	keyC           Key = "C"
+	keyN           Key = "N"
+	keyZ           Key = "Z"
+	keyY           Key = "Y"
+	keyV           Key = "V"
+	keyF11         Key = "F11"
+	keyControl     Key = "Control"
+	keyAlt         Key = "Alt"
+)
+
+// GamepadButton is a button of a gamepad with the standard layout, numbered as
+// in the standard gamepad of the W3C Gamepad API. The standard layout names
+// buttons by their position; on an Xbox controller, the bottom and right face
+// buttons are A and B.
+type GamepadButton int
+
+// The buttons of the standard layout the game is played with.
+const (
+	buttonRightBottom   GamepadButton = 0  // Bottom face button
+	buttonRightRight    GamepadButton = 1  // Right face button
+	buttonFrontTopLeft  GamepadButton = 4  // Left shoulder button
+	buttonFrontTopRight GamepadButton = 5  // Right shoulder button
+	buttonCenterRight   GamepadButton = 9  // Right one of the center buttons, e.g. Start
+	buttonLeftTop       GamepadButton = 12 // Up on the d-pad
+	buttonLeftBottom    GamepadButton = 13 // Down on the d-pad
+	buttonLeftLeft      GamepadButton = 14 // Left on the d-pad
+	buttonLeftRight     GamepadButton = 15 // Right on the d-pad
+)
+
+// GamepadID identifies a connected gamepad.
+type GamepadID int
+
+// TouchID identifies a finger on the touch screen while it touches it.
+type TouchID int
+
+// Input is the state of the keyboard, the mouse, the touch screen and the
+// gamepads in the current update. Durations count the updates a key or button
+// has been held down, including the current one; they are 0 while it is up.
+type Input interface {
+	KeyPressDuration(key Key) int
+	// MousePressDuration is the duration of the left mouse button.
+	MousePressDuration() int
+	// CursorPosition returns the screen position of the mouse cursor.
+	CursorPosition() (x, y int)
+
+	// JustPressedTouchIDs returns the fingers that touched the screen in this update.
+	JustPressedTouchIDs() []TouchID
+	// IsTouchJustReleased reports whether the finger was lifted in this update.
+	IsTouchJustReleased(id TouchID) bool
+	// TouchPosition returns the screen position of the finger, or where it
+	// was lifted if it was lifted in this update.
+	TouchPosition(id TouchID) (x, y int)
+
+	// JustConnectedGamepadIDs returns the gamepads connected in this update.
+	JustConnectedGamepadIDs() []GamepadID
+	// IsGamepadJustDisconnected reports whether the gamepad was disconnected in this update.
+	IsGamepadJustDisconnected(id GamepadID) bool
+	GamepadName(id GamepadID) string
+	// IsStandardGamepad reports whether the buttons of the gamepad are mapped
+	// onto the standard layout, see GamepadButton.
+	IsStandardGamepad(id GamepadID) bool
+	GamepadButtonPressDuration(id GamepadID, button GamepadButton) int
+	// LeftStick returns the axis values of the left stick, each from -1 to 1.
+	// Positive values point right and down.
+	LeftStick(id GamepadID) (horizontal, vertical float64)
+}
+
+// Screen tells where the game screen is drawn in a layout, so that
+// clicks and taps can be matched with what is drawn there.
+type Screen interface {
+	// SettingsOptionAt returns the index of the option of the settings menu
+	// with the given number of options at the screen position in the layout
+	// l, or -1.
+	SettingsOptionAt(l layout.Layout, x, y, numOptions int) int
+	// DistributionColorAt returns the color of the miniature in the color
+	// distribution of the given colors and shapes at the screen position in
+	// the layout l, or nil.
+	DistributionColorAt(l layout.Layout, breakdown map[color.Color]scoring.ColorBreakdown, shapes map[tiles.Cell]scoring.Shape, x, y int) color.Color
+	// IsShareCodeClicked reports whether the screen position is on the share
+	// code in the layout l.
+	IsShareCodeClicked(l layout.Layout, x, y int, code string) bool
+}
+
+// Clipboard holds text copied by the player or the game.
+type Clipboard interface {
+	Read() string
+	Write(text string)
+}
+
+// Sound plays the sounds of the game.
+type Sound interface {
+	// PlayMoveSound plays the sound of a move, sped up or slowed down to the
+	// given duration of its animation in seconds.
+	PlayMoveSound(duration float64)
+}
+
+// Window is the window the game is shown in.
+type Window interface {
+	IsFullscreen() bool
+	SetFullscreen(fullscreen bool)
+	// SetIcon sets the icon of the window, in several sizes.
+	SetIcon(icons []image.Image)
+}
@@ -0,0 +1,95 @@
+package game
+
+// Move is a swap of the tiles in column X1, row Y1 and column X2, row Y2.
+type Move struct {
+	X1, Y1 int
+	X2, Y2 int
+}
+
+// Replay records how a board was played: the board as it started, with the
+// settings of its share code, the rules it was scored under, and the moves
+// applied to it in order. The rules are recorded even when the share code
+// leaves out the default rules, so the replay always scores the same. Moves that
+// were queued during an animation are recorded when they are applied, so the
+// replay is the same as if the player had waited.
+type Replay struct {
+	ShareCode string
+	RuleSetID string // ID of the scoring rule set, see scoring.LookupRuleSet
+	Moves     []Move
+}
+
+// moveKind tells how a move on the board came about.
+type moveKind int
+
+const (
+	movePlayed moveKind = iota // A new move of the player
+	moveUndone                 // A move swapped back by undo
+	moveRedone                 // A move made again by redo
+)
+
+// startReplay starts recording the moves on the current board.
+func (g *Game) startReplay() {
+	g.replay = Replay{ShareCode: g.shareCode, RuleSetID: g.ruleSetID}
+	g.undone = nil
+}
+
+// recordMove updates the replay and the moves that can be redone for a move
+// that has been applied to the board. An undone move leaves the replay, and a
+// new move can no longer be followed by the undone ones.
+func (g *Game) recordMove(kind moveKind, x1, y1, x2, y2 int) {
+	switch kind {
+	case moveUndone:
+		last := len(g.replay.Moves) - 1
+		g.undone = append(g.undone, g.replay.Moves[last])
+		g.replay.Moves = g.replay.Moves[:last]
+		return
+	case moveRedone:
+		g.undone = g.undone[:len(g.undone)-1]
+	default:
+		g.undone = nil
+	}
+	g.replay.Moves = append(g.replay.Moves, Move{X1: x1, Y1: y1, X2: x2, Y2: y2})
+}
+
+// canRewind reports whether moves can be undone or redone right now: not while
+// a move animates, on the results screen, or while the settings menu is open.
+// Challenges never rewind, because taking moves back would refund their budget.
+func (g *Game) canRewind() bool {
+	return g.mode != ModeChallenge && !g.board.IsAnimating && !g.isGameOver && !g.isSettingsOpen
+}
+
+// undo swaps the tiles of the last move back. Its cost is refunded, and its
+// score leaves the score history once the tiles are back in place.
+func (g *Game) undo() {
+	if !g.canRewind() || len(g.replay.Moves) == 0 {
+		return
+	}
+	m := g.replay.Moves[len(g.replay.Moves)-1]
+	g.board.SetMaxMoveCost(0) // Taking a move back is always allowed
+	if !g.board.Swap(m.X1, m.Y1, m.X2, m.Y2) {
+		return
+	}
+	g.animating = moveUndone
+	g.preview = nil
+	g.moveCost -= g.board.MoveCost()
+	g.sound.PlayMoveSound(g.board.AnimationDuration())
+}
+
+// redo makes the last undone move again, like a new move.
+func (g *Game) redo() {
+	if !g.canRewind() || len(g.undone) == 0 {
+		return
+	}
+	m := g.undone[len(g.undone)-1]
+	if !g.swap(m.X1, m.Y1, m.X2, m.Y2) {
+		return // E.g. the budget of a challenge doesn't allow the move anymore
+	}
+	g.animating = moveRedone
+}
+
+// Replay returns the record of the current board.
+func (g *Game) Replay() Replay {
+	replay := g.replay
+	replay.Moves = append([]Move(nil), g.replay.Moves...)
+	return replay
+}
@@ -0,0 +1,34 @@
+package game
+
+// countdown measures a time limit in game ticks instead of wall-clock time.
+// It runs at game speed and can be advanced deterministically in tests.
+type countdown struct {
+	ticksLeft int
+	tps       int
+}
+
+// newCountdown creates a countdown over the given number of seconds at the given tick rate.
+func newCountdown(seconds, tps int) countdown {
+	return countdown{ticksLeft: seconds * tps, tps: tps}
+}
+
+// Tick advances the countdown by one game tick.
+func (c *countdown) Tick() {
+	if c.ticksLeft > 0 {
+		c.ticksLeft--
+	}
+}
+
+// Expired reports whether the time limit has been reached.
+func (c countdown) Expired() bool {
+	return c.ticksLeft <= 0
+}
+
+// SecondsLeft returns the remaining time in whole seconds, rounded up,
+// so the display only shows 0 once the time is really up.
+func (c countdown) SecondsLeft() int {
+	if c.tps <= 0 {
+		return 0
+	}
+	return (c.ticksLeft + c.tps - 1) / c.tps
+}
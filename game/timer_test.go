@@ -0,0 +1,42 @@
+package game
+
+import "testing"
+
+func TestCountdown(t *testing.T) {
+	c := newCountdown(2, 60)
+	if c.Expired() {
+		t.Fatal("Expected a new countdown not to be expired")
+	}
+	if c.SecondsLeft() != 2 {
+		t.Errorf("Expected 2 seconds left, got %d", c.SecondsLeft())
+	}
+
+	// After one tick the partial second is rounded up.
+	c.Tick()
+	if c.SecondsLeft() != 2 {
+		t.Errorf("Expected 2 seconds left after one tick, got %d", c.SecondsLeft())
+	}
+
+	for i := 1; i < 60; i++ {
+		c.Tick()
+	}
+	if c.SecondsLeft() != 1 {
+		t.Errorf("Expected 1 second left after 60 ticks, got %d", c.SecondsLeft())
+	}
+
+	for i := 0; i < 60; i++ {
+		c.Tick()
+	}
+	if !c.Expired() {
+		t.Error("Expected the countdown to expire after 120 ticks")
+	}
+	if c.SecondsLeft() != 0 {
+		t.Errorf("Expected 0 seconds left, got %d", c.SecondsLeft())
+	}
+
+	// Ticking an expired countdown has no effect.
+	c.Tick()
+	if c.SecondsLeft() != 0 || !c.Expired() {
+		t.Error("Expected an expired countdown to stay expired")
+	}
+}
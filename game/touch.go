@@ -0,0 +1,153 @@
+package game
+
+import (
+	"fmt"
+	"time"
+	"zenmojo/board"
+	"zenmojo/config"
+	"zenmojo/scene"
+	"zenmojo/tiles"
+)
+
+// touchGesture follows the first finger that touches the screen until it is
+// lifted. A short touch is a tap and acts like a click. A finger that moves
+// from a tile drags it, and a finger that rests on a tile for
+// config.LongPressDuration shows a hint for it.
+//
+// The game is played with one finger: further fingers that touch the screen
+// meanwhile cancel the gesture, so that e.g. a pinch neither taps nor swaps.
+type touchGesture struct {
+	id             TouchID
+	start          time.Time
+	startX, startY int  // Screen position where the finger touched the screen
+	x, y           int  // Current screen position of the finger
+	moved          bool // Whether the finger has left the spot it touched, so it is not a tap
+	hinting        bool // Whether the finger has rested long enough to show a hint
+	cancelled      bool // Whether the gesture was cancelled, e.g. by a second finger
+}
+
+// updateTouch follows the fingers on the screen.
+func (g *Game) updateTouch() {
+	for _, id := range g.input.JustPressedTouchIDs() {
+		x, y := g.input.TouchPosition(id)
+		g.touchStart(id, x, y)
+	}
+	if g.touch == nil {
+		return
+	}
+	if g.input.IsTouchJustReleased(g.touch.id) {
+		g.touchEnd(g.input.TouchPosition(g.touch.id))
+		return
+	}
+	g.touchMove(g.input.TouchPosition(g.touch.id))
+}
+
+// touchStart begins a gesture for a finger that touched the screen at (x, y),
+// or cancels the current one if another finger is already down.
+func (g *Game) touchStart(id TouchID, x, y int) {
+	if g.touch != nil {
+		g.touch.cancelled = true
+		g.drag = nil
+		g.preview = nil
+		return
+	}
+	g.touch = &touchGesture{id: id, start: g.clock.Now(), startX: x, startY: y, x: x, y: y}
+	g.cursorVisible = false // Touch is used, so the keyboard cursor steps aside
+}
+
+// touchMove follows the finger to (x, y). Once it has moved away from a tile,
+// the tile is dragged; if it rests on a tile long enough, a hint is shown.
+func (g *Game) touchMove(x, y int) {
+	t := g.touch
+	t.x, t.y = x, y
+	if t.cancelled || t.hinting {
+		return
+	}
+	i, j, onCell := board.CellAt(g.layout, t.startX, t.startY)
+
+	if !t.moved && movedFurther(t.startX, t.startY, x, y, config.DragThreshold) {
+		t.moved = true
+		if onCell && !g.board.IsAnimating && !g.isGameOver {
+			g.drag = &dragGesture{i: i, j: j, startX: t.startX, startY: t.startY, active: true}
+		}
+	}
+	if !t.moved && onCell && !g.board.IsAnimating && !g.isGameOver && g.clock.Now().Sub(t.start).Seconds() >= config.LongPressDuration {
+		t.hinting = true
+		g.showHint(i, j)
+	}
+}
+
+// touchEnd finishes the gesture of the finger that was lifted at (x, y).
+func (g *Game) touchEnd(x, y int) {
+	t := g.touch
+	g.touch = nil
+	switch {
+	case t.cancelled:
+		g.drag = nil
+	case g.drag != nil:
+		g.drop(x, y)
+	case t.hinting:
+		g.preview = nil // The hint disappears with the finger
+	case !t.moved:
+		g.tap(t.startX, t.startY)
+	}
+}
+
+// tap handles a tap at (x, y). It acts like a click, also while a move or the
+// transition between zen boards plays, see busyClick.
+func (g *Game) tap(x, y int) {
+	if g.isSettingsOpen {
+		g.chooseSetting(g.screen.SettingsOptionAt(g.layout, x, y, len(g.settingsOptions())))
+		return
+	}
+	if g.board.IsAnimating || g.transition != nil {
+		g.busyClick(x, y)
+		return
+	}
+	g.click(x, y)
+}
+
+// showHint previews the swap of the tile at (i, j) that raises the score the
+// most, or tells the player that there is none.
+func (g *Game) showHint(i, j int) {
+	x1, y1, x2, y2, delta, ok := g.bestSwap(i, j)
+	if !ok || delta <= 0 {
+		g.preview = nil
+		g.showFeedback("No swap of this tile raises the score")
+		return
+	}
+	g.preview = &scene.Preview{X1: x1, Y1: y1, X2: x2, Y2: y2, Delta: delta}
+	g.showFeedback(fmt.Sprintf("Hint: %+d", delta))
+}
+
+// bestSwap returns the swap of the tile at (i, j) that the board allows and
+// that changes the score the most. Of equally good swaps, the cheapest one is
+// chosen. ok is false if the tile can't be swapped at all.
+func (g *Game) bestSwap(i, j int) (x1, y1, x2, y2, delta int, ok bool) {
+	if g.board.Cells().At(j, i) == tiles.Empty {
+		return 0, 0, 0, 0, 0, false // An empty cell is not a tile
+	}
+	bestCost := 0
+	for y := 0; y < config.GridSize; y++ {
+		for x := 0; x < config.GridSize; x++ {
+			if !g.board.CanSwap(i, j, x, y) {
+				continue
+			}
+			d := g.scorer.SwapDelta(j, i, y, x)
+			cost, _ := g.swapMode.Cost(i, j, x, y)
+			if !ok || d > delta || d == delta && cost < bestCost {
+				x1, y1, x2, y2, delta, bestCost, ok = i, j, x, y, d, cost, true
+			}
+		}
+	}
+	return x1, y1, x2, y2, delta, ok
+}
+
+// pointer returns the screen position of the finger while one touches the
+// screen, and of the mouse cursor otherwise.
+func (g *Game) pointer() (x, y int) {
+	if g.touch != nil {
+		return g.touch.x, g.touch.y
+	}
+	return g.input.CursorPosition()
+}
@@ -0,0 +1,89 @@
+package game
+
+import (
+	"testing"
+	"time"
+	"zenmojo/config"
+)
+
+// touchTap touches the cell in column i and row j and lifts the finger right away.
+func touchTap(g *Game, i, j int) {
+	x, y := cellCenter(i, j)
+	g.touchStart(1, x, y)
+	g.touchEnd(x, y)
+}
+
+func TestTapSelectsAndSwaps(t *testing.T) {
+	g, _ := newTestGame(ModeClassic, blockGrid())
+	touchTap(g, 1, 0)
+	if x, y := g.board.Selected(); x != 1 || y != 0 {
+		t.Fatalf("Expected a tap to select the tile at (1, 0), got (%d, %d)", x, y)
+	}
+	touchTap(g, 2, 0)
+	if !g.board.IsAnimating || g.moveCost != 1 {
+		t.Errorf("Expected a second tap to swap the tiles as one move, got animating %v and cost %d", g.board.IsAnimating, g.moveCost)
+	}
+
+	// Taps during the move are queued like clicks.
+	touchTap(g, 5, 5)
+	if len(g.queuedCells) != 1 {
+		t.Errorf("Expected the tap during the move to be queued, got %d queued cells", len(g.queuedCells))
+	}
+}
+
+func TestTouchDragSwaps(t *testing.T) {
+	g, _ := newTestGame(ModeClassic, blockGrid())
+	x, y := cellCenter(1, 0)
+	targetX, targetY := cellCenter(2, 0)
+	g.touchStart(1, x, y)
+	g.touchMove(targetX, targetY)
+	if d := g.dragView(); d == nil || d.I != 1 || d.J != 0 {
+		t.Fatalf("Expected the touched tile to be dragged, got %+v", d)
+	}
+	if px, py := g.pointer(); px != targetX || py != targetY {
+		t.Errorf("Expected the dragged tile to follow the finger to (%d, %d), got (%d, %d)", targetX, targetY, px, py)
+	}
+	g.touchEnd(targetX, targetY)
+	if !g.board.IsAnimating || g.moveCost != 1 {
+		t.Errorf("Expected the drop to swap the tiles as one move, got animating %v and cost %d", g.board.IsAnimating, g.moveCost)
+	}
+}
+
+func TestLongPressShowsHint(t *testing.T) {
+	g, fake := newTestGame(ModeClassic, blockGrid())
+	x, y := cellCenter(1, 0)
+	g.touchStart(1, x, y)
+	g.touchMove(x, y)
+	if g.touch.hinting {
+		t.Fatal("Expected no hint before the finger has rested on the tile")
+	}
+
+	fake.Advance(time.Duration(config.LongPressDuration*float64(time.Second)) + time.Millisecond)
+	g.touchMove(x+1, y)
+	if p := g.preview; p == nil || p.X2 != 2 || p.Y2 != 0 || p.Delta != 200 {
+		t.Fatalf("Expected a hint to swap with (2, 0) for 200 points, got %+v", p)
+	}
+
+	// Lifting the finger removes the hint without selecting the tile.
+	g.touchEnd(x, y)
+	if x, y := g.board.Selected(); g.preview != nil || x != -1 || y != -1 {
+		t.Errorf("Expected the hint to disappear and nothing to be selected, got selection (%d, %d)", x, y)
+	}
+}
+
+func TestSecondFingerCancelsGesture(t *testing.T) {
+	g, _ := newTestGame(ModeClassic, blockGrid())
+	g.click(cellCenter(1, 0))
+
+	x, y := cellCenter(2, 0)
+	otherX, otherY := cellCenter(5, 5)
+	g.touchStart(1, x, y)
+	g.touchStart(2, otherX, otherY)
+	g.touchEnd(x, y)
+	if g.board.IsAnimating {
+		t.Error("Expected a touch with two fingers not to swap")
+	}
+	if g.touch != nil {
+		t.Error("Expected the gesture to end when the first finger is lifted")
+	}
+}
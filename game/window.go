@@ -0,0 +1,14 @@
+package game
+
+// toggleFullscreen switches between fullscreen and the window.
+func (g *Game) toggleFullscreen() {
+	g.window.SetFullscreen(!g.window.IsFullscreen())
+}
+
+// onOff names the state of a setting that is switched on or off, for the settings menu.
+func onOff(on bool) string {
+	if on {
+		return "On"
+	}
+	return "Off"
+}
@@ -0,0 +1,131 @@
+// Package layout arranges the areas of the game screen for the size of the
+// window. In portrait, the score graph sits above the board and the stone
+// distribution below it; in landscape, both move into a panel beside the
+// board. The board grows with the window, with larger tiles. Drawing and
+// hit-testing are given the same layout, so that clicks always land where
+// things are drawn.
+package layout
+
+import (
+	"image"
+	"math"
+	"zenmojo/config"
+)
+
+const (
+	statusBarHeight = 30 // Height of the status bar at the top of the screen
+	shareCodeHeight = 30 // Height of the strip at the bottom of the screen that holds the share code
+	graphMargin     = 20 // Space between the score graph and the board or the stone distribution
+	panelMargin     = 24 // Space around the board in landscape
+)
+
+// Layout holds the screen areas of the game for one canvas size. All areas are
+// in canvas pixels.
+type Layout struct {
+	Width, Height int
+	Landscape     bool // Whether the graph and the distribution are beside the board rather than above and below it
+
+	StatusBar    image.Rectangle
+	Board        image.Rectangle
+	Square       int // Size of a tile on the board, config.SquareSize on the smallest canvas
+	Gap          int // Space between two tiles, config.Gap on the smallest canvas
+	Graph        image.Rectangle
+	Distribution image.Rectangle // Area in which the stone distribution is centered
+	ShareCode    image.Rectangle // Strip in which the share code is centered
+}
+
+// Fit returns the layout for a window of the given size in pixels. Of
+// portrait and landscape, it chooses the one that fits the window with the
+// least scaling. A window that is too small for the chosen layout gets a
+// larger canvas of the same aspect ratio, which is scaled down to the window.
+func Fit(windowWidth, windowHeight int) Layout {
+	if windowWidth <= 0 || windowHeight <= 0 {
+		// E.g. a minimized window; keep the canvas the game was designed for
+		return New(config.ScreenWidth, config.ScreenHeight, false)
+	}
+	portrait := enlargement(windowWidth, windowHeight, config.ScreenWidth, config.ScreenHeight)
+	landscape := enlargement(windowWidth, windowHeight, config.LandscapeWidth, config.LandscapeHeight)
+	isLandscape := landscape < portrait || landscape == portrait && windowWidth > windowHeight
+
+	scale := portrait
+	if isLandscape {
+		scale = landscape
+	}
+	width := int(math.Round(float64(windowWidth) * scale))
+	height := int(math.Round(float64(windowHeight) * scale))
+	return New(width, height, isLandscape)
+}
+
+// enlargement returns the factor by which a window has to be enlarged to hold
+// a canvas of at least minWidth x minHeight, or 1 if it is large enough.
+func enlargement(width, height, minWidth, minHeight int) float64 {
+	return math.Max(1, math.Max(float64(minWidth)/float64(width), float64(minHeight)/float64(height)))
+}
+
+// New returns the layout for a canvas of the given size, in portrait or
+// landscape. The board takes the room that the other areas leave, at least
+// what it has on the smallest canvas of the orientation.
+func New(width, height int, landscape bool) Layout {
+	l := Layout{
+		Width:     width,
+		Height:    height,
+		Landscape: landscape,
+		StatusBar: image.Rect(0, 0, width, statusBarHeight),
+		ShareCode: image.Rect(0, height-shareCodeHeight, width, height),
+	}
+	if !landscape {
+		size := l.fitBoard(width-(config.ScreenWidth-config.GridWidth), height-(config.ScreenHeight-config.GridHeight))
+		x := (width - size) / 2
+		y := (height - size) / 2
+		l.Board = image.Rect(x, y, x+size, y+size)
+		l.Graph = image.Rect(0, statusBarHeight, width, y-graphMargin)
+		l.Distribution = image.Rect(0, l.Board.Max.Y, width, height)
+		return l
+	}
+
+	// The board and the panel beside it are centered as a block
+	panelWidth := config.ScreenWidth
+	size := l.fitBoard(width-(config.LandscapeWidth-config.GridWidth), height-(config.LandscapeHeight-config.GridHeight))
+	blockWidth := size + panelMargin + panelWidth
+	x := (width - blockWidth) / 2
+	y := statusBarHeight + (height-statusBarHeight-shareCodeHeight-size)/2
+	l.Board = image.Rect(x, y, x+size, y+size)
+
+	panelX := l.Board.Max.X + panelMargin
+	graphHeight := size * 3 / 10
+	l.Graph = image.Rect(panelX, y, panelX+panelWidth, y+graphHeight)
+	l.Distribution = image.Rect(panelX, l.Graph.Max.Y+graphMargin, panelX+panelWidth, l.Board.Max.Y)
+	return l
+}
+
+// fitBoard sets the size of the tiles and the gaps for a board that may be
+// up to width x height pixels large. Tiles and gaps keep the proportions of
+// config.SquareSize and config.Gap. It returns the size of the board.
+func (l *Layout) fitBoard(width, height int) int {
+	scale := float64(min(width, height)) / float64(config.GridWidth)
+	l.Square = max(1, int(float64(config.SquareSize)*scale))
+	l.Gap = int(float64(config.Gap) * scale)
+	return config.GridSize*l.Square + (config.GridSize-1)*l.Gap
+}
+
+// Cell returns the screen position of the top-left corner of the cell in
+// column i and row j.
+func (l Layout) Cell(i, j int) (x, y int) {
+	return l.Board.Min.X + i*(l.Square+l.Gap), l.Board.Min.Y + j*(l.Square+l.Gap)
+}
+
+// CellAt returns the cell under the given screen position.
+// ok is false if the position is not on a cell (e.g. in a gap).
+func (l Layout) CellAt(x, y int) (i, j int, ok bool) {
+	if x < l.Board.Min.X || y < l.Board.Min.Y {
+		return -1, -1, false
+	}
+	pitch := l.Square + l.Gap
+	i = (x - l.Board.Min.X) / pitch
+	j = (y - l.Board.Min.Y) / pitch
+	if i >= config.GridSize || j >= config.GridSize ||
+		(x-l.Board.Min.X)%pitch >= l.Square || (y-l.Board.Min.Y)%pitch >= l.Square {
+		return -1, -1, false
+	}
+	return i, j, true
+}
@@ -0,0 +1,121 @@
+package layout
+
+import (
+	"image"
+	"testing"
+	"zenmojo/config"
+)
+
+func TestFitChoosesOrientation(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		windowW, windowH      int
+		wantLandscape         bool
+		wantWidth, wantHeight int
+	}{
+		{"design size", config.ScreenWidth, config.ScreenHeight, false, config.ScreenWidth, config.ScreenHeight},
+		{"full HD monitor", 1920, 1080, true, 1920, 1080},
+		{"phone in portrait", 1080, 2340, false, 1080, 2340},
+		{"small portrait window", 300, 490, false, config.ScreenWidth, config.ScreenHeight},
+		{"small landscape window", 600, 330, true, config.LandscapeWidth, config.LandscapeHeight},
+		{"square window", 800, 800, false, 980, 980},
+		{"minimized window", 0, 0, false, config.ScreenWidth, config.ScreenHeight},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := Fit(tc.windowW, tc.windowH)
+			if l.Landscape != tc.wantLandscape {
+				t.Errorf("Expected landscape %v, got %v", tc.wantLandscape, l.Landscape)
+			}
+			if l.Width != tc.wantWidth || l.Height != tc.wantHeight {
+				t.Errorf("Expected a %dx%d canvas, got %dx%d", tc.wantWidth, tc.wantHeight, l.Width, l.Height)
+			}
+		})
+	}
+}
+
+func TestAreasFitAndDoNotOverlap(t *testing.T) {
+	for _, size := range []image.Point{
+		{config.ScreenWidth, config.ScreenHeight},
+		{config.LandscapeWidth, config.LandscapeHeight},
+		{1920, 1080}, {1080, 2340}, {800, 800}, {2560, 700},
+	} {
+		l := Fit(size.X, size.Y)
+		canvas := image.Rect(0, 0, l.Width, l.Height)
+		areas := map[string]image.Rectangle{
+			"status bar":   l.StatusBar,
+			"board":        l.Board,
+			"graph":        l.Graph,
+			"distribution": l.Distribution,
+			"share code":   l.ShareCode,
+		}
+		for name, area := range areas {
+			if area.Empty() || !area.In(canvas) {
+				t.Errorf("%v: expected the %s %v to be on the %v canvas", size, name, area, canvas)
+			}
+		}
+		if l.Board.Dx() < config.GridWidth || l.Board.Dy() != l.Board.Dx() || l.Square < config.SquareSize {
+			t.Errorf("%v: expected a square board at least as large as on the smallest canvas, got %v with %dpx tiles", size, l.Board, l.Square)
+		}
+		if x, y := l.Cell(config.GridSize-1, config.GridSize-1); x+l.Square > l.Board.Max.X || y+l.Square > l.Board.Max.Y {
+			t.Errorf("%v: expected the last cell at (%d, %d) to be on the board %v", size, x, y, l.Board)
+		}
+		for name, area := range areas {
+			if name != "board" && area.Overlaps(l.Board) {
+				t.Errorf("%v: expected the %s %v not to overlap the board %v", size, name, area, l.Board)
+			}
+		}
+		if l.Graph.Overlaps(l.Distribution) || l.Graph.Overlaps(l.StatusBar) {
+			t.Errorf("%v: expected the graph %v to leave room for the status bar and the distribution", size, l.Graph)
+		}
+		if l.Landscape && (l.Graph.Min.X < l.Board.Max.X || l.Distribution.Min.X < l.Board.Max.X) {
+			t.Errorf("%v: expected the graph and the distribution beside the board in landscape", size)
+		}
+	}
+}
+
+func TestBoardGrowsWithCanvas(t *testing.T) {
+	testCases := []struct {
+		name                string
+		windowW, windowH    int
+		wantSquare, wantGap int
+	}{
+		{"design size", config.ScreenWidth, config.ScreenHeight, config.SquareSize, config.Gap},
+		{"smallest landscape", config.LandscapeWidth, config.LandscapeHeight, config.SquareSize, config.Gap},
+		{"small window", 300, 490, config.SquareSize, config.Gap},
+		{"full HD monitor", 1920, 1080, 84, 14},
+		{"fullscreen on a QHD monitor", 2560, 1440, 115, 19},
+		{"phone in portrait", 1080, 2340, 89, 14},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := Fit(tc.windowW, tc.windowH)
+			if l.Square != tc.wantSquare || l.Gap != tc.wantGap {
+				t.Errorf("Expected %dpx tiles with %dpx gaps, got %dpx and %dpx", tc.wantSquare, tc.wantGap, l.Square, l.Gap)
+			}
+		})
+	}
+}
+
+func TestCellAtFindsCell(t *testing.T) {
+	for _, l := range []Layout{New(config.ScreenWidth, config.ScreenHeight, false), Fit(1920, 1080)} {
+		for i := 0; i < config.GridSize; i++ {
+			for j := 0; j < config.GridSize; j++ {
+				x, y := l.Cell(i, j)
+				for _, p := range []image.Point{{x, y}, {x + l.Square - 1, y + l.Square - 1}} {
+					if gotI, gotJ, ok := l.CellAt(p.X, p.Y); !ok || gotI != i || gotJ != j {
+						t.Errorf("CellAt(%d, %d) = (%d, %d, %v), want (%d, %d, true)", p.X, p.Y, gotI, gotJ, ok, i, j)
+					}
+				}
+				if _, _, ok := l.CellAt(x+l.Square, y); ok && i == config.GridSize-1 {
+					t.Errorf("Expected no cell right of the board at (%d, %d)", x+l.Square, y)
+				}
+				if _, _, ok := l.CellAt(x-1, y); ok {
+					t.Errorf("Expected no cell in the gap at (%d, %d)", x-1, y)
+				}
+			}
+		}
+	}
+}
@@ -4,9 +4,9 @@ import (
 	_ "embed"
 	"errors"
 	"log"
+	"zenmojo/app"
 	"zenmojo/audio"
 	"zenmojo/config"
-	"zenmojo/game"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"golang.design/x/clipboard"
@@ -24,19 +24,24 @@ func main() {
 
 	// Set window properties from the config package
 	ebiten.SetWindowIcon(config.Icons)
-	// Adjust window size for the device's scale factor to prevent blurriness and clipping.
+	// Restore the window of the last run. On the first run, adjust the window size
+	// for the device's scale factor to prevent blurriness and clipping.
 	// This makes the game DPI-aware.
-	scale := ebiten.Monitor().DeviceScaleFactor()
-	ebiten.SetWindowSize(int(float64(config.ScreenWidth)/scale), int(float64(config.ScreenHeight)/scale))
+	if !app.RestoreWindow() {
+		scale := ebiten.Monitor().DeviceScaleFactor()
+		ebiten.SetWindowSize(int(float64(config.ScreenWidth)/scale), int(float64(config.ScreenHeight)/scale))
+	}
 
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	// Closing the window saves its geometry before the game ends, see App.Update
+	ebiten.SetWindowClosingHandled(true)
 	ebiten.SetWindowTitle("Zengo")
 
 	// Create a new audio manager
 	audioManager := audio.NewManager(moveSoundFile)
 
 	// Create and run the game
-	game := game.NewGame(audioManager)
-	if err := ebiten.RunGame(game); err != nil {
+	if err := ebiten.RunGame(app.New(audioManager)); err != nil {
 		// ebiten.Termination is a sentinel error indicating a clean exit.
 		if !errors.Is(err, ebiten.Termination) {
 			log.Fatal(err)
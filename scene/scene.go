@@ -0,0 +1,92 @@
+// Package scene describes what the screen shows as plain values: the board
+// with the state of its animations, the status bar, the overlays and the menus.
+// The game builds a Frame in every update and package view draws it, so the
+// game itself can run and be tested without a display.
+package scene
+
+import (
+	"fmt"
+	"image/color"
+	"zenmojo/board"
+	"zenmojo/layout"
+	"zenmojo/scoring"
+	"zenmojo/tiles"
+)
+
+// Frame is everything the screen shows at one moment.
+type Frame struct {
+	Layout        layout.Layout
+	Board         *board.Board
+	Status        Status
+	ScoreHistory  []int
+	Breakdown     map[color.Color]scoring.ColorBreakdown // Score contribution of each color
+	Shapes        map[tiles.Cell]scoring.Shape           // Required shape of each color in target-shape puzzles, or nil
+	Highlight     color.Color                            // Color whose tiles stand out while the others are dimmed, or nil
+	Outlines      []Outline
+	Preview       *Preview    // Swap the player is considering, or nil
+	Drag          *Drag       // Tile dragged with the mouse or a finger, or nil
+	Cursor        *Cursor     // Board cursor moved with the keyboard, or nil while it is hidden
+	Transition    *Transition // Animation between two boards in zen mode, or nil
+	MouseX        int         // Screen position of the mouse cursor or the finger on the screen
+	MouseY        int
+	Results       *Results // Results of a finished game, or nil while it is played
+	Settings      *Menu    // Open settings menu, or nil
+	ShareCode     string
+	Feedback      string // Short message shown in place of the share code, or ""
+	IsCustomBoard bool   // Whether the board was pasted rather than generated
+}
+
+// Status holds the values shown in the status bar.
+type Status struct {
+	Score    int
+	MaxScore int
+	MoveCost int
+	Budget   int // Move budget of a challenge, 0 if there is none
+	TimeLeft int // Remaining seconds in time-attack mode, -1 if there is no time limit
+	Boards   int // Boards completed in an endless session, 0 if there is no session
+}
+
+// Outline frames the bounding box of a scoring group, given in grid rows and columns.
+type Outline struct {
+	MinR, MaxR int
+	MinC, MaxC int
+	Glow       float64 // Strength of the glow, from 1 when the group started scoring down to 0
+}
+
+// Preview describes a swap that the player is considering: the tiles at the
+// grid positions (X1, Y1) and (X2, Y2), and how much the score would change.
+type Preview struct {
+	X1, Y1, X2, Y2 int
+	Delta          int
+}
+
+// Drag describes a tile that the player drags with the mouse: the tile at the
+// grid position (I, J), grabbed at (GrabX, GrabY) relative to its top left corner.
+type Drag struct {
+	I, J         int
+	GrabX, GrabY int
+}
+
+// Cursor is the board cursor that is moved with the keyboard, at the grid position (I, J).
+type Cursor struct {
+	I, J int
+}
+
+// Results is the end-of-game screen: a title followed by one line of text per entry.
+type Results struct {
+	Title string
+	Lines []string
+}
+
+// Menu is a menu drawn on top of the board, with the option that the keyboard
+// or a gamepad chooses at index Focus.
+type Menu struct {
+	Title   string
+	Options []string
+	Focus   int
+}
+
+// FormatTime formats a number of seconds as minutes and seconds, e.g. "1:05".
+func FormatTime(seconds int) string {
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
@@ -0,0 +1,50 @@
+package scene
+
+import (
+	"image/color"
+	"time"
+	"zenmojo/board"
+)
+
+// Transition animates the change from a finished board to the next one.
+// In the first half the tiles of the finished board dissolve, in the second
+// half the tiles of the new board fade in.
+type Transition struct {
+	from     [][]color.Color
+	start    time.Time
+	progress float64
+	duration float64
+}
+
+// NewTransition creates a transition away from the given finished board,
+// starting at the given time. The duration is given in seconds.
+func NewTransition(from *board.Board, duration float64, start time.Time) *Transition {
+	// Keep a copy, so the finished board can be drawn while the game moves on.
+	return &Transition{from: from.Grid(), start: start, duration: duration}
+}
+
+// Update advances the transition to the given time.
+// It returns true when the transition is finished.
+func (t *Transition) Update(now time.Time) (finished bool) {
+	if t.duration > 0 {
+		t.progress = now.Sub(t.start).Seconds() / t.duration
+	} else {
+		t.progress = 1.0
+	}
+	if t.progress >= 1.0 {
+		t.progress = 1.0
+		return true
+	}
+	return false
+}
+
+// Progress returns how far the transition has played, from 0 to 1.
+func (t *Transition) Progress() float64 {
+	return t.progress
+}
+
+// From returns the color of the tile at grid position (i, j) of the finished
+// board, or nil if the cell was empty.
+func (t *Transition) From(i, j int) color.Color {
+	return t.from[j][i]
+}
@@ -0,0 +1,89 @@
+package scoring
+
+import (
+	"image/color"
+	"zenmojo/tiles"
+)
+
+// ShapeKind classifies the current arrangement of a color's items.
+type ShapeKind int
+
+const (
+	// KindLine means all items form a single row or column.
+	KindLine ShapeKind = iota
+	// KindRectangle means all items form a solid rectangle.
+	KindRectangle
+	// KindIrregular means all items are connected, but not as a line or solid rectangle.
+	KindIrregular
+	// KindSplit means the items are spread over more than one group.
+	KindSplit
+)
+
+// String returns a human-readable name for the shape kind.
+func (k ShapeKind) String() string {
+	switch k {
+	case KindLine:
+		return "Line"
+	case KindRectangle:
+		return "Rectangle"
+	case KindIrregular:
+		return "Irregular"
+	default:
+		return "Split"
+	}
+}
+
+// ColorBreakdown describes how a single color contributes to the score.
+type ColorBreakdown struct {
+	Color     color.Color
+	Items     int     // Number of items of the color on the board
+	Groups    []Group // Contiguous groups of the color, including single items
+	Complete  bool    // Whether all items form a single group
+	Kind      ShapeKind
+	Points    int // Points the color currently scores
+	MaxPoints int // Points the color scores in its best arrangement
+}
+
+// Breakdown returns the score contribution of every color on the grid under
+// the given rules, keyed by the color of its tiles in the palette. The points
+// of all colors add up to Score.
+func Breakdown(grid tiles.Grid, palette []color.Color, rule ScoringRule) map[color.Color]ColorBreakdown {
+	byCell := make(map[tiles.Cell]ColorBreakdown)
+	for _, group := range collectGroups(grid, 1) {
+		b := byCell[group.Cell]
+		b.Items += len(group.Coordinates)
+		b.Groups = append(b.Groups, group)
+		if len(group.Coordinates) >= 2 {
+			b.Points += rule.Calculate(group, grid)
+		}
+		byCell[group.Cell] = b
+	}
+
+	breakdown := make(map[color.Color]ColorBreakdown, len(byCell))
+	for cell, b := range byCell {
+		b.Color = cell.Color(palette)
+		b.Complete = len(b.Groups) == 1
+		b.Kind = shapeKind(b.Groups)
+		b.MaxPoints = rule.MaxPossibleScore(grid.Only(cell))
+		breakdown[b.Color] = b
+	}
+	return breakdown
+}
+
+// shapeKind classifies the groups of a single color.
+func shapeKind(groups []Group) ShapeKind {
+	if len(groups) != 1 {
+		return KindSplit
+	}
+	g := groups[0]
+	width := g.MaxC - g.MinC + 1
+	height := g.MaxR - g.MinR + 1
+	switch {
+	case width == 1 || height == 1:
+		return KindLine
+	case len(g.Coordinates) == width*height:
+		return KindRectangle
+	default:
+		return KindIrregular
+	}
+}
@@ -0,0 +1,64 @@
+package scoring
+
+import (
+	"image/color"
+	"testing"
+	"zenmojo/tiles"
+)
+
+func TestBreakdown(t *testing.T) {
+	red := tiles.CellOf(0)
+	blue := tiles.CellOf(1)
+	green := tiles.CellOf(2)
+	yellow := tiles.CellOf(3)
+	grid := gridOf([][]tiles.Cell{
+		{red, red, blue, green},
+		{red, red, green, green},
+		{yellow, yellow, yellow, blue},
+	})
+	palette := []color.Color{color.Gray{Y: 1}, color.Gray{Y: 2}, color.Gray{Y: 3}, color.Gray{Y: 4}}
+
+	breakdown := Breakdown(grid, palette, StandardRuleSet{})
+
+	testCases := []struct {
+		name     string
+		cell     tiles.Cell
+		expected ColorBreakdown
+	}{
+		{"Rectangle", red, ColorBreakdown{Items: 4, Complete: true, Kind: KindRectangle, Points: 16, MaxPoints: 16}},
+		{"Split", blue, ColorBreakdown{Items: 2, Complete: false, Kind: KindSplit, Points: 0, MaxPoints: 2}},
+		{"Irregular", green, ColorBreakdown{Items: 3, Complete: true, Kind: KindIrregular, Points: 0, MaxPoints: 3}},
+		{"Line", yellow, ColorBreakdown{Items: 3, Complete: true, Kind: KindLine, Points: 3, MaxPoints: 3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok := breakdown[tc.cell.Color(palette)]
+			if !ok {
+				t.Fatal("Expected the color in the breakdown")
+			}
+			if actual.Items != tc.expected.Items || actual.Complete != tc.expected.Complete ||
+				actual.Kind != tc.expected.Kind || actual.Points != tc.expected.Points ||
+				actual.MaxPoints != tc.expected.MaxPoints {
+				t.Errorf("Expected %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+
+	if groups := len(breakdown[blue.Color(palette)].Groups); groups != 2 {
+		t.Errorf("Expected the split color to have 2 groups, got %d", groups)
+	}
+
+	// The points of all colors add up to the total score.
+	total, maxTotal := 0, 0
+	for _, b := range breakdown {
+		total += b.Points
+		maxTotal += b.MaxPoints
+	}
+	if expected := Score(grid, StandardRuleSet{}); total != expected {
+		t.Errorf("Expected the points to add up to %d, got %d", expected, total)
+	}
+	if expected := MaxScore(grid); maxTotal != expected {
+		t.Errorf("Expected the max points to add up to %d, got %d", expected, maxTotal)
+	}
+}
@@ -0,0 +1,88 @@
+package scoring
+
+import "zenmojo/tiles"
+
+// CompactnessRuleSet rewards progress towards the optimal shapes instead of
+// scoring only finished rectangles. Every group earns partial credit for how
+// many of its color's items it gathers and how compact it is, so the score
+// rises smoothly while the board is being arranged.
+//
+// Partial credit never exceeds half of the optimal score of a color, and groups
+// that score under the standard rules keep that score. Perfect rectangles
+// therefore remain the best possible result.
+type CompactnessRuleSet struct{}
+
+// Calculate scores a single group by the better of the standard rules and its
+// partial credit.
+func (s CompactnessRuleSet) Calculate(group Group, grid tiles.Grid) int {
+	return s.calculateCounted(group, grid.Count(group.Cell), grid)
+}
+
+func (s CompactnessRuleSet) calculateCounted(group Group, totalItems int, grid tiles.Grid) int {
+	standard := StandardRuleSet{}.calculateCounted(group, totalItems, grid)
+
+	numItems := len(group.Coordinates)
+	if numItems < 2 || totalItems < 2 {
+		return standard
+	}
+
+	// The share of the color's items squared, so that merging two groups is
+	// always worth more than keeping them apart.
+	share := float64(numItems) / float64(totalItems)
+	_, _, optimalScore := OptimalShape(totalItems)
+	partial := int(float64(optimalScore) * share * share * Compactness(group) / 2)
+
+	if partial > standard {
+		return partial
+	}
+	return standard
+}
+
+// MaxPossibleScore returns the score of a board where every color forms its
+// optimal rectangle, which is the same as under the standard rules.
+func (s CompactnessRuleSet) MaxPossibleScore(grid tiles.Grid) int {
+	return MaxScore(grid)
+}
+
+// Compactness returns how close the group's outline is to the shortest outline
+// that any group of the same size can have, from just above 0 for a sprawling
+// group to 1 for the most compact one.
+func Compactness(group Group) float64 {
+	numItems := len(group.Coordinates)
+	if numItems == 0 {
+		return 0
+	}
+
+	cells := make(map[Coordinate]bool, numItems)
+	for _, cell := range group.Coordinates {
+		cells[cell] = true
+	}
+
+	// Every side of a cell that doesn't touch another cell of the group is part of the outline.
+	perimeter := 0
+	for _, cell := range group.Coordinates {
+		neighbors := []Coordinate{
+			{R: cell.R - 1, C: cell.C},
+			{R: cell.R + 1, C: cell.C},
+			{R: cell.R, C: cell.C - 1},
+			{R: cell.R, C: cell.C + 1},
+		}
+		for _, n := range neighbors {
+			if !cells[n] {
+				perimeter++
+			}
+		}
+	}
+
+	return float64(minPerimeter(numItems)) / float64(perimeter)
+}
+
+// minPerimeter returns the shortest outline of any group of n cells,
+// which is 2 * ceil(2 * sqrt(n)).
+func minPerimeter(n int) int {
+	k := 0
+	for k*k < 4*n {
+		k++
+	}
+	return 2 * k
+}
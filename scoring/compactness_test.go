@@ -0,0 +1,78 @@
+package scoring
+
+import (
+	"math/rand"
+	"testing"
+	"zenmojo/tiles"
+)
+
+func TestCompactnessRuleSet_Calculate(t *testing.T) {
+	red := tiles.CellOf(0)
+	empty := tiles.Empty
+
+	testCases := []struct {
+		name          string
+		grid          [][]tiles.Cell
+		expectedScore int
+	}{
+		{
+			name: "Optimal rectangle scores like the standard rules",
+			grid: [][]tiles.Cell{
+				{red, red, red},
+				{red, red, red},
+			},
+			expectedScore: 36,
+		},
+		{
+			name: "Compact non-rectangle gets partial credit",
+			grid: [][]tiles.Cell{
+				{red, red, red, red},
+				{red, red, empty, empty},
+			},
+			expectedScore: 15, // 36 * (10 / 12) / 2
+		},
+		{
+			name: "Line gets more partial credit than its standard score",
+			grid: [][]tiles.Cell{
+				{red, red, red, red, red, red},
+			},
+			expectedScore: 12, // 36 * (10 / 14) / 2
+		},
+		{
+			name: "Split color gets credit for each part",
+			grid: [][]tiles.Cell{
+				{red, red, red, empty, red, red, red},
+			},
+			expectedScore: 8, // 2 * 36 * (1/2)^2 * (8 / 8) / 2
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Score(gridOf(tc.grid), CompactnessRuleSet{}); actual != tc.expectedScore {
+				t.Errorf("Expected score %d, but got %d", tc.expectedScore, actual)
+			}
+		})
+	}
+}
+
+// TestCompactnessRuleSetBounds checks on random boards that the compactness
+// rules never score below the standard rules or above the maximum score.
+func TestCompactnessRuleSetBounds(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	colors := []tiles.Cell{tiles.CellOf(0), tiles.CellOf(1), tiles.CellOf(2), tiles.CellOf(3)}
+
+	for trial := 0; trial < 200; trial++ {
+		grid := tiles.New(6, 6)
+		for i := range grid.Cells {
+			grid.Cells[i] = colors[rng.Intn(len(colors))]
+		}
+
+		score := Score(grid, CompactnessRuleSet{})
+		standard := Score(grid, StandardRuleSet{})
+		maxScore := CompactnessRuleSet{}.MaxPossibleScore(grid)
+		if score < standard || score > maxScore {
+			t.Fatalf("Trial %d: expected a score between %d and %d, got %d", trial, standard, maxScore, score)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package scoring
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"zenmojo/tiles"
+)
+
+// Shape classes that a declarative rule set assigns formulas to.
+const (
+	ClassLine      = "line"      // A single row or column
+	ClassSquare    = "square"    // A solid rectangle with equal sides
+	ClassRectangle = "rectangle" // Any other solid rectangle
+	ClassOther     = "other"     // Any shape that is not a solid rectangle
+)
+
+// defaultFormulas are used for shape classes that a rule file leaves out.
+// They match the standard rules. A missing square formula falls back to the
+// rectangle formula instead.
+var defaultFormulas = map[string]string{
+	ClassLine:      "items",
+	ClassRectangle: "items * width * height",
+	ClassOther:     "0",
+}
+
+// ruleFile is the JSON representation of a declarative rule set, for example:
+//
+//	{
+//	  "id": "double-squares",
+//	  "name": "Squares score double",
+//	  "shapes": {
+//	    "square": "2 * items * width * height",
+//	    "line": "items + 10 * (items >= 5)"
+//	  }
+//	}
+type ruleFile struct {
+	ID     string            `json:"id"`
+	Name   string            `json:"name"`
+	Shapes map[string]string `json:"shapes"`
+}
+
+// DeclarativeRuleSet scores groups by formulas that depend on their shape class.
+// Like in the standard rules, a group only scores if it contains all items of its color.
+type DeclarativeRuleSet struct {
+	formulas map[string]Formula
+}
+
+// ParseRuleSet compiles a declarative rule set from JSON.
+func ParseRuleSet(data []byte) (RuleSet, error) {
+	var file ruleFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return RuleSet{}, fmt.Errorf("invalid rule file: %v", err)
+	}
+
+	if file.ID == "" {
+		return RuleSet{}, errors.New(`missing "id"`)
+	}
+	if strings.IndexFunc(file.ID, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	}) >= 0 {
+		return RuleSet{}, fmt.Errorf("id %q may only contain letters, digits, '-' and '_'", file.ID)
+	}
+	if file.Name == "" {
+		return RuleSet{}, errors.New(`missing "name"`)
+	}
+
+	sources := make(map[string]string)
+	for class, expr := range defaultFormulas {
+		sources[class] = expr
+	}
+	for class, expr := range file.Shapes {
+		if _, ok := defaultFormulas[class]; !ok && class != ClassSquare {
+			return RuleSet{}, fmt.Errorf("unknown shape class %q (expected %s, %s, %s or %s)",
+				class, ClassLine, ClassSquare, ClassRectangle, ClassOther)
+		}
+		sources[class] = expr
+	}
+	if _, ok := sources[ClassSquare]; !ok {
+		sources[ClassSquare] = sources[ClassRectangle]
+	}
+
+	rules := DeclarativeRuleSet{formulas: make(map[string]Formula)}
+	for _, class := range []string{ClassLine, ClassSquare, ClassRectangle, ClassOther} {
+		formula, err := ParseFormula(sources[class])
+		if err != nil {
+			return RuleSet{}, fmt.Errorf("formula for shape class %q: %v", class, err)
+		}
+		rules.formulas[class] = formula
+	}
+	return RuleSet{ID: file.ID, Name: file.Name, Rule: rules}, nil
+}
+
+// LoadRuleSetFile reads and compiles a declarative rule set from a JSON file.
+// Errors name the file they occurred in.
+func LoadRuleSetFile(path string) (RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RuleSet{}, err
+	}
+	rs, err := ParseRuleSet(data)
+	if err != nil {
+		return RuleSet{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return rs, nil
+}
+
+// LoadRuleSetDir compiles all JSON rule files in a directory, in alphabetical order.
+// Files that fail to load are skipped and reported in the returned error.
+// A missing directory yields no rule sets.
+func LoadRuleSetDir(dir string) ([]RuleSet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var ruleSets []RuleSet
+	var errs []error
+	for _, path := range paths {
+		rs, err := LoadRuleSetFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ruleSets = append(ruleSets, rs)
+	}
+	return ruleSets, errors.Join(errs...)
+}
+
+// DefaultRulesDir returns the directory for house rule files in the user's config directory.
+func DefaultRulesDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "Zengo", "rules"), nil
+}
+
+// Calculate scores a group by the formula of its shape class.
+func (d DeclarativeRuleSet) Calculate(group Group, grid tiles.Grid) int {
+	return d.calculateCounted(group, grid.Count(group.Cell), grid)
+}
+
+func (d DeclarativeRuleSet) calculateCounted(group Group, totalItems int, grid tiles.Grid) int {
+	numItems := len(group.Coordinates)
+	if numItems < 2 || numItems != totalItems {
+		return 0 // Only score groups that contain all items of that color.
+	}
+	width := group.MaxC - group.MinC + 1
+	height := group.MaxR - group.MinR + 1
+	return d.formulas[shapeClass(numItems, width, height)](numItems, width, height)
+}
+
+// MaxPossibleScore returns the sum of the best score of each color. It tries
+// every bounding box that a group of the color's items can have and that fits
+// on the grid, and takes the best score among them. Unlike MaxScore, it can't
+// assume that the best shape fits: a formula may e.g. reward lines longer than
+// the board.
+func (d DeclarativeRuleSet) MaxPossibleScore(grid tiles.Grid) int {
+	total := 0
+	for _, numItems := range grid.Counts() {
+		if numItems < 2 {
+			continue
+		}
+		best := 0 // A scattered color scores 0, so the best score is never lower.
+		for width := 1; width <= min(numItems, grid.Width); width++ {
+			for height := 1; height <= min(numItems, grid.Height); height++ {
+				area := width * height
+				isLine := (width == 1 || height == 1) && area == numItems
+				isSolid := width > 1 && height > 1 && area == numItems
+				// A connected group needs at least width+height-1 items to span its
+				// bounding box, and fewer items than its area to leave a hole.
+				isOther := width > 1 && height > 1 && area > numItems && width+height-1 <= numItems
+				if !isLine && !isSolid && !isOther {
+					continue
+				}
+				score := d.formulas[shapeClass(numItems, width, height)](numItems, width, height)
+				if score > best {
+					best = score
+				}
+			}
+		}
+		total += best
+	}
+	return total
+}
+
+// shapeClass returns the class of a group with the given number of items and bounding box.
+func shapeClass(numItems, width, height int) string {
+	switch {
+	case width == 1 || height == 1:
+		return ClassLine
+	case numItems != width*height:
+		return ClassOther
+	case width == height:
+		return ClassSquare
+	default:
+		return ClassRectangle
+	}
+}
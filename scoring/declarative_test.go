@@ -0,0 +1,165 @@
+package scoring
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"zenmojo/tiles"
+)
+
+func TestParseRuleSet_Calculate(t *testing.T) {
+	rs, err := ParseRuleSet([]byte(`{
+		"id": "house",
+		"name": "House rules",
+		"shapes": {
+			"square": "2 * items * width * height",
+			"line": "items + 10 * (items >= 5)",
+			"other": "items"
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rs.ID != "house" || rs.Name != "House rules" {
+		t.Errorf("Unexpected id %q or name %q", rs.ID, rs.Name)
+	}
+
+	red := tiles.CellOf(0)
+	empty := tiles.Empty
+	testCases := []struct {
+		name          string
+		grid          [][]tiles.Cell
+		expectedScore int
+	}{
+		{"Square scores double", [][]tiles.Cell{{red, red}, {red, red}}, 32},
+		{"Rectangle uses the default formula", [][]tiles.Cell{{red, red, red}, {red, red, red}}, 36},
+		{"Long line gets a bonus", [][]tiles.Cell{{red, red, red, red, red}}, 15},
+		{"Short line gets no bonus", [][]tiles.Cell{{red, red, red, red}}, 4},
+		{"Other shape", [][]tiles.Cell{{red, red}, {red, empty}}, 3},
+		{"Incomplete group", [][]tiles.Cell{{red, red, empty, red}}, 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Score(gridOf(tc.grid), rs.Rule); actual != tc.expectedScore {
+				t.Errorf("Expected score %d, but got %d", tc.expectedScore, actual)
+			}
+		})
+	}
+}
+
+func TestDeclarativeRuleSet_MaxPossibleScore(t *testing.T) {
+	// Without any formulas, the rule set behaves like the standard rules.
+	standard, err := ParseRuleSet([]byte(`{"id": "plain", "name": "Plain"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	colors := []tiles.Cell{tiles.CellOf(0), tiles.CellOf(1), tiles.CellOf(2), tiles.CellOf(3)}
+	for trial := 0; trial < 50; trial++ {
+		// The tiles fill a 5x5 corner of a board that is large enough for every
+		// shape, as MaxScore assumes.
+		grid := tiles.New(25, 25)
+		for r := 0; r < 5; r++ {
+			for c := 0; c < 5; c++ {
+				grid.Set(r, c, colors[rng.Intn(len(colors))])
+			}
+		}
+		if actual, expected := standard.Rule.MaxPossibleScore(grid), MaxScore(grid); actual != expected {
+			t.Fatalf("Trial %d: expected the standard max score %d, got %d", trial, expected, actual)
+		}
+	}
+
+	// Other shapes can be the best choice, e.g. 5 items spanning a 3x3 box.
+	rewardsOther, err := ParseRuleSet([]byte(`{"id": "other", "name": "Other", "shapes": {"other": "width * height"}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	red := tiles.CellOf(0)
+	empty := tiles.Empty
+	grid := gridOf([][]tiles.Cell{
+		{red, red, red, red},
+		{red, empty, empty, empty},
+		{empty, empty, empty, empty},
+	})
+	if actual := rewardsOther.Rule.MaxPossibleScore(grid); actual != 9 {
+		t.Errorf("Expected a max score of 9 for the largest bounding box, got %d", actual)
+	}
+
+	// Only shapes that fit on the board count: more items than the board is
+	// long can't form a line, however much lines are rewarded.
+	rewardsLines, err := ParseRuleSet([]byte(`{"id": "lines", "name": "Lines", "shapes": {"line": "100", "square": "items", "rectangle": "items", "other": "1"}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	blue := tiles.CellOf(1)
+	const size = 10 // The size of the game board
+	board := tiles.New(size, size)
+	for i := 0; i < size+5; i++ {
+		board.Set(i/size, i%size, red)
+	}
+	for c := 0; c < size; c++ {
+		board.Set(size-1, c, blue)
+	}
+	// Red's 15 items are best as a 3x5 rectangle, blue's 10 items fit in a line.
+	if actual, expected := rewardsLines.Rule.MaxPossibleScore(board), 15+100; actual != expected {
+		t.Errorf("Expected a max score of %d with red's line too long for the board, got %d", expected, actual)
+	}
+}
+
+func TestParseRuleSetErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		data          string
+		expectedError string
+	}{
+		{"invalid JSON", `{"id": "x",`, "invalid rule file"},
+		{"unknown field", `{"id": "x", "name": "X", "shape": {}}`, `unknown field "shape"`},
+		{"missing id", `{"name": "X"}`, `missing "id"`},
+		{"invalid id", `{"id": "x~y", "name": "X"}`, `id "x~y" may only contain`},
+		{"missing name", `{"id": "x"}`, `missing "name"`},
+		{"unknown class", `{"id": "x", "name": "X", "shapes": {"circle": "1"}}`, `unknown shape class "circle"`},
+		{"invalid formula", `{"id": "x", "name": "X", "shapes": {"line": "items +"}}`, `formula for shape class "line": unexpected "end of formula" at position 8`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseRuleSet([]byte(tc.data))
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("Expected error containing %q, got %q", tc.expectedError, err.Error())
+			}
+		})
+	}
+}
+
+func TestLoadRuleSetDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.json":  `{"id": "a", "name": "A"}`,
+		"b.json":  `{"id": "b"}`,
+		"c.json":  `{"id": "c", "name": "C"}`,
+		"readme":  `not a rule file`,
+		"d.json~": `{}`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ruleSets, err := LoadRuleSetDir(dir)
+	if len(ruleSets) != 2 || ruleSets[0].ID != "a" || ruleSets[1].ID != "c" {
+		t.Errorf("Expected rule sets a and c, got %+v", ruleSets)
+	}
+	if err == nil || !strings.Contains(err.Error(), "b.json") {
+		t.Errorf("Expected an error naming b.json, got %v", err)
+	}
+
+	if ruleSets, err := LoadRuleSetDir(filepath.Join(dir, "missing")); err != nil || len(ruleSets) != 0 {
+		t.Errorf("Expected no rule sets and no error for a missing directory, got %v, %v", ruleSets, err)
+	}
+}
@@ -0,0 +1,316 @@
+package scoring
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Formula computes the score of a group from its number of items and the
+// width and height of its bounding box.
+type Formula func(items, width, height int) int
+
+// formulaVars holds the values of the variables a formula can use.
+type formulaVars struct {
+	items, width, height int
+}
+
+// formulaNode is a compiled part of a formula.
+type formulaNode func(v formulaVars) int
+
+// ParseFormula compiles an integer arithmetic expression over the variables
+// items, width and height. It supports +, -, *, / and %, parentheses, the
+// functions min and max, and the comparisons <, <=, >, >=, == and !=, which
+// evaluate to 1 if true and 0 otherwise. For example, "items + 10 * (items >= 5)"
+// gives lines of five or more items a bonus. Division by zero yields 0.
+func ParseFormula(expr string) (Formula, error) {
+	tokens, err := tokenizeFormula(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &formulaParser{tokens: tokens}
+	node, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEnd {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return func(items, width, height int) int {
+		return node(formulaVars{items: items, width: width, height: height})
+	}, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+)
+
+type formulaToken struct {
+	kind tokenKind
+	text string
+	pos  int // Position in the expression, starting at 1
+}
+
+// formulaOperators lists the operators, longest first so that "<=" wins over "<".
+var formulaOperators = []string{"<=", ">=", "==", "!=", "<", ">", "+", "-", "*", "/", "%", "(", ")", ","}
+
+func tokenizeFormula(expr string) ([]formulaToken, error) {
+	var tokens []formulaToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, formulaToken{kind: tokenNumber, text: string(runes[start:i]), pos: start + 1})
+		case unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, formulaToken{kind: tokenIdent, text: string(runes[start:i]), pos: start + 1})
+		default:
+			matched := false
+			for _, op := range formulaOperators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, formulaToken{kind: tokenOperator, text: op, pos: i + 1})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i+1)
+			}
+		}
+	}
+	return append(tokens, formulaToken{kind: tokenEnd, text: "end of formula", pos: len(runes) + 1}), nil
+}
+
+// formulaParser is a recursive descent parser for formulas.
+type formulaParser struct {
+	tokens []formulaToken
+	next   int
+}
+
+func (p *formulaParser) peek() formulaToken {
+	return p.tokens[p.next]
+}
+
+func (p *formulaParser) advance() formulaToken {
+	tok := p.tokens[p.next]
+	if tok.kind != tokenEnd {
+		p.next++
+	}
+	return tok
+}
+
+func (p *formulaParser) errorf(tok formulaToken, format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), tok.pos)
+}
+
+// isOperator reports whether the next token is one of the given operators.
+func (p *formulaParser) isOperator(ops ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *formulaParser) parseComparison() (formulaNode, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator("<", "<=", ">", ">=", "==", "!=") {
+		return left, nil
+	}
+	op := p.advance().text
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	compare := map[string]func(a, b int) bool{
+		"<":  func(a, b int) bool { return a < b },
+		"<=": func(a, b int) bool { return a <= b },
+		">":  func(a, b int) bool { return a > b },
+		">=": func(a, b int) bool { return a >= b },
+		"==": func(a, b int) bool { return a == b },
+		"!=": func(a, b int) bool { return a != b },
+	}[op]
+	return func(v formulaVars) int {
+		if compare(left(v), right(v)) {
+			return 1
+		}
+		return 0
+	}, nil
+}
+
+func (p *formulaParser) parseSum() (formulaNode, error) {
+	node, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("+", "-") {
+		op := p.advance().text
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left := node
+		if op == "+" {
+			node = func(v formulaVars) int { return left(v) + right(v) }
+		} else {
+			node = func(v formulaVars) int { return left(v) - right(v) }
+		}
+	}
+	return node, nil
+}
+
+func (p *formulaParser) parseProduct() (formulaNode, error) {
+	node, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("*", "/", "%") {
+		op := p.advance().text
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left := node
+		switch op {
+		case "*":
+			node = func(v formulaVars) int { return left(v) * right(v) }
+		case "/":
+			node = func(v formulaVars) int {
+				if d := right(v); d != 0 {
+					return left(v) / d
+				}
+				return 0
+			}
+		case "%":
+			node = func(v formulaVars) int {
+				if d := right(v); d != 0 {
+					return left(v) % d
+				}
+				return 0
+			}
+		}
+	}
+	return node, nil
+}
+
+func (p *formulaParser) parseUnary() (formulaNode, error) {
+	if p.isOperator("-") {
+		p.advance()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(v formulaVars) int { return -operand(v) }, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *formulaParser) parsePrimary() (formulaNode, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case tokenNumber:
+		value, err := strconv.Atoi(tok.text)
+		if err != nil {
+			return nil, p.errorf(tok, "number %s is too large", tok.text)
+		}
+		return func(formulaVars) int { return value }, nil
+
+	case tokenIdent:
+		if p.isOperator("(") {
+			return p.parseCall(tok)
+		}
+		switch tok.text {
+		case "items":
+			return func(v formulaVars) int { return v.items }, nil
+		case "width":
+			return func(v formulaVars) int { return v.width }, nil
+		case "height":
+			return func(v formulaVars) int { return v.height }, nil
+		}
+		return nil, p.errorf(tok, "unknown variable %q (expected items, width or height)", tok.text)
+
+	case tokenOperator:
+		if tok.text == "(" {
+			node, err := p.parseComparison()
+			if err != nil {
+				return nil, err
+			}
+			if !p.isOperator(")") {
+				return nil, p.errorf(p.peek(), "expected \")\" but found %q", p.peek().text)
+			}
+			p.advance()
+			return node, nil
+		}
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+// parseCall parses the arguments of a call to min or max.
+func (p *formulaParser) parseCall(name formulaToken) (formulaNode, error) {
+	var pick func(a, b int) bool
+	switch name.text {
+	case "min":
+		pick = func(a, b int) bool { return a < b }
+	case "max":
+		pick = func(a, b int) bool { return a > b }
+	default:
+		return nil, p.errorf(name, "unknown function %q (expected min or max)", name.text)
+	}
+
+	p.advance() // "("
+	var args []formulaNode
+	for {
+		arg, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.isOperator(",") {
+			p.advance()
+			continue
+		}
+		if p.isOperator(")") {
+			p.advance()
+			break
+		}
+		return nil, p.errorf(p.peek(), "expected \",\" or \")\" but found %q", p.peek().text)
+	}
+	if len(args) < 2 {
+		return nil, p.errorf(name, "%s needs at least two arguments", name.text)
+	}
+
+	return func(v formulaVars) int {
+		result := args[0](v)
+		for _, arg := range args[1:] {
+			if value := arg(v); pick(value, result) {
+				result = value
+			}
+		}
+		return result
+	}, nil
+}
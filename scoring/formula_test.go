@@ -0,0 +1,68 @@
+package scoring
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFormula(t *testing.T) {
+	testCases := []struct {
+		expr                 string
+		items, width, height int
+		expected             int
+	}{
+		{"items", 6, 2, 3, 6},
+		{"items * width * height", 6, 2, 3, 36},
+		{"2 * items * width * height", 4, 2, 2, 32},
+		{"items + 10 * (items >= 5)", 5, 5, 1, 15},
+		{"items + 10 * (items >= 5)", 4, 4, 1, 4},
+		{"(items + 1) * 2 - width", 3, 3, 1, 5},
+		{"-items + 20", 6, 2, 3, 14},
+		{"items / 4 + items % 4", 6, 2, 3, 3},
+		{"items / (width - 2)", 6, 2, 3, 0}, // Division by zero
+		{"max(width, height) * min(width, height, 10)", 6, 2, 3, 6},
+		{"width == height", 4, 2, 2, 1},
+		{"width != height", 4, 2, 2, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			formula, err := ParseFormula(tc.expr)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if actual := formula(tc.items, tc.width, tc.height); actual != tc.expected {
+				t.Errorf("Expected %d, got %d", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseFormulaErrors(t *testing.T) {
+	testCases := []struct {
+		expr          string
+		expectedError string
+	}{
+		{"", `unexpected "end of formula" at position 1`},
+		{"items *", `unexpected "end of formula" at position 8`},
+		{"item * 2", `unknown variable "item" (expected items, width or height) at position 1`},
+		{"(items + 1", `expected ")" but found "end of formula" at position 11`},
+		{"items $ 2", `unexpected character '$' at position 7`},
+		{"items 2", `unexpected "2" at position 7`},
+		{"pow(items, 2)", `unknown function "pow" (expected min or max) at position 1`},
+		{"max(items)", `max needs at least two arguments at position 1`},
+		{"99999999999999999999", `number 99999999999999999999 is too large at position 1`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := ParseFormula(tc.expr)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("Expected error %q, got %q", tc.expectedError, err.Error())
+			}
+		})
+	}
+}
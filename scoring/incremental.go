@@ -0,0 +1,177 @@
+package scoring
+
+import (
+	"image/color"
+	"sort"
+	"zenmojo/tiles"
+)
+
+// IncrementalScorer keeps the score of a grid up to date while its items are
+// swapped. It remembers the cells and points of every color, so a swap only
+// rescores the two colors involved instead of the whole grid. This relies on
+// every rule set scoring a group by the items of its own color only.
+//
+// Its score always equals Score of the same grid and rules.
+type IncrementalScorer struct {
+	cells   tiles.Grid
+	rule    ScoringRule
+	colors  []colorState // Indexed by cell
+	total   int
+	visited []int // Flood fill marks, see flood
+	pass    int
+}
+
+// colorState holds the cells of a color in row-major order and the points they score.
+type colorState struct {
+	cells     []Coordinate
+	points    int
+	maxPoints int // Points in the best arrangement, -1 until Breakdown needs them
+}
+
+// NewIncrementalScorer scores a copy of the grid. Later changes to the grid
+// are not seen by the scorer; use Swap instead.
+func NewIncrementalScorer(grid tiles.Grid, rule ScoringRule) *IncrementalScorer {
+	maxCell := tiles.Empty
+	for _, cell := range grid.Cells {
+		maxCell = max(maxCell, cell)
+	}
+	s := &IncrementalScorer{
+		cells:   grid.Clone(),
+		rule:    rule,
+		colors:  make([]colorState, int(maxCell)+1),
+		visited: make([]int, len(grid.Cells)),
+	}
+	for cell := range s.colors {
+		s.colors[cell].maxPoints = -1
+	}
+	for r := 0; r < grid.Height; r++ {
+		for c := 0; c < grid.Width; c++ {
+			if cell := grid.At(r, c); cell != tiles.Empty {
+				s.colors[cell].cells = append(s.colors[cell].cells, Coordinate{R: r, C: c})
+			}
+		}
+	}
+	for cell := range s.colors[1:] {
+		s.rescore(tiles.Cell(cell + 1))
+	}
+	return s
+}
+
+// Score returns the score of the current grid.
+func (s *IncrementalScorer) Score() int {
+	return s.total
+}
+
+// Swap exchanges the items at (r1, c1) and (r2, c2) and returns the new score.
+// Either item may be empty.
+func (s *IncrementalScorer) Swap(r1, c1, r2, c2 int) int {
+	a, b := s.cells.At(r1, c1), s.cells.At(r2, c2)
+	if a == b {
+		return s.total
+	}
+	s.cells.Swap(r1, c1, r2, c2)
+
+	from, to := Coordinate{R: r1, C: c1}, Coordinate{R: r2, C: c2}
+	if a != tiles.Empty {
+		s.move(a, from, to)
+	}
+	if b != tiles.Empty {
+		s.move(b, to, from)
+	}
+	return s.total
+}
+
+// SwapDelta returns how much the score changes if the items at (r1, c1) and
+// (r2, c2) are exchanged. The grid is left as it is.
+func (s *IncrementalScorer) SwapDelta(r1, c1, r2, c2 int) int {
+	before := s.total
+	after := s.Swap(r1, c1, r2, c2)
+	s.Swap(r1, c1, r2, c2)
+	return after - before
+}
+
+// move moves one tile of a color and rescores the color.
+func (s *IncrementalScorer) move(cell tiles.Cell, from, to Coordinate) {
+	cells := s.colors[cell].cells
+	i := sort.Search(len(cells), func(i int) bool { return !rowMajorLess(cells[i], from) })
+	cells = append(cells[:i], cells[i+1:]...)
+
+	j := sort.Search(len(cells), func(j int) bool { return !rowMajorLess(cells[j], to) })
+	cells = append(cells, Coordinate{})
+	copy(cells[j+1:], cells[j:])
+	cells[j] = to
+
+	s.colors[cell].cells = cells
+	s.rescore(cell)
+}
+
+// rescore finds the groups of a color and updates its points and the total score.
+// Groups are collected in the same order and with the same cells as by
+// collectGroups, so the rules see exactly what Score shows them.
+func (s *IncrementalScorer) rescore(cell tiles.Cell) {
+	state := &s.colors[cell]
+	s.pass++
+	points := 0
+	for _, coord := range state.cells {
+		if s.visited[coord.R*s.cells.Width+coord.C] == s.pass {
+			continue
+		}
+		group := Group{Cell: cell, MinR: coord.R, MaxR: coord.R, MinC: coord.C, MaxC: coord.C}
+		flood(s.cells, s.visited, s.pass, coord.R, coord.C, cell, &group)
+		if len(group.Coordinates) >= 2 {
+			points += calculateGroup(s.rule, group, len(state.cells), s.cells)
+		}
+	}
+	s.total += points - state.points
+	state.points = points
+}
+
+// Breakdown returns the score contribution of every color, like the function
+// Breakdown of the same grid and rules. The items and points of a color come
+// from what the scorer keeps for it; only its groups are collected anew. The
+// best arrangement of a color doesn't change with swaps, so its points are
+// worked out once.
+func (s *IncrementalScorer) Breakdown(palette []color.Color) map[color.Color]ColorBreakdown {
+	breakdown := make(map[color.Color]ColorBreakdown)
+	for i := 1; i < len(s.colors); i++ {
+		state := &s.colors[i]
+		if len(state.cells) == 0 {
+			continue
+		}
+		cell := tiles.Cell(i)
+		if state.maxPoints < 0 {
+			state.maxPoints = s.rule.MaxPossibleScore(s.cells.Only(cell))
+		}
+		b := ColorBreakdown{
+			Color:     cell.Color(palette),
+			Items:     len(state.cells),
+			Groups:    s.groups(cell),
+			Points:    state.points,
+			MaxPoints: state.maxPoints,
+		}
+		b.Complete = len(b.Groups) == 1
+		b.Kind = shapeKind(b.Groups)
+		breakdown[b.Color] = b
+	}
+	return breakdown
+}
+
+// groups returns the groups of a color, including single items, in the order
+// of collectGroups.
+func (s *IncrementalScorer) groups(cell tiles.Cell) []Group {
+	var groups []Group
+	s.pass++
+	for _, coord := range s.colors[cell].cells {
+		if s.visited[coord.R*s.cells.Width+coord.C] == s.pass {
+			continue
+		}
+		group := Group{Cell: cell, MinR: coord.R, MaxR: coord.R, MinC: coord.C, MaxC: coord.C}
+		flood(s.cells, s.visited, s.pass, coord.R, coord.C, cell, &group)
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func rowMajorLess(a, b Coordinate) bool {
+	return a.R < b.R || a.R == b.R && a.C < b.C
+}
@@ -0,0 +1,226 @@
+package scoring
+
+import (
+	"image/color"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"zenmojo/tiles"
+)
+
+// plainRule scores like the standard rules but only implements Calculate, so
+// the scorer has to fall back to it.
+type plainRule struct{}
+
+func (plainRule) Calculate(group Group, grid tiles.Grid) int {
+	return StandardRuleSet{}.Calculate(group, grid)
+}
+
+func (plainRule) MaxPossibleScore(grid tiles.Grid) int {
+	return MaxScore(grid)
+}
+
+// incrementalRules returns all registered rule sets, the target-shape rules with
+// shapes for the test colors, and a rule that only implements ScoringRule.
+func incrementalRules() []RuleSet {
+	shapes := map[tiles.Cell]Shape{
+		tiles.CellOf(0): NewShape("L", "X.", "X.", "XX"),
+		tiles.CellOf(1): NewShape("Square", "XX", "XX"),
+	}
+	return append(RuleSets(),
+		RuleSet{ID: "test-shapes", Name: "Shapes", Rule: ShapeRuleSet{Shapes: shapes, AllowTransforms: true}},
+		RuleSet{ID: "test-plain", Name: "Plain", Rule: plainRule{}},
+	)
+}
+
+// randomGrid returns a grid of a few colors and some empty cells, so that
+// groups form and break often.
+func randomGrid(rng *rand.Rand, rows, cols int) tiles.Grid {
+	cells := []tiles.Cell{tiles.CellOf(0), tiles.CellOf(1), tiles.CellOf(2), tiles.Empty}
+	grid := tiles.New(cols, rows)
+	for i := range grid.Cells {
+		grid.Cells[i] = cells[rng.Intn(len(cells))]
+	}
+	return grid
+}
+
+// referenceScore scores the grid the way the game did before it stored boards
+// as tiles: it finds the groups with a recursive search over the grid as
+// colors and scores each one with rule.Calculate. It shares no code with Score
+// and the incremental scorer, so the tests can check both against it.
+func referenceScore(grid tiles.Grid, rule ScoringRule) int {
+	colors := grid.Colors(referencePalette)
+	rows, cols := len(colors), len(colors[0])
+	visited := make([][]bool, rows)
+	for i := range visited {
+		visited[i] = make([]bool, cols)
+	}
+
+	var dfs func(r, c int, target color.Color, group *Group)
+	dfs = func(r, c int, target color.Color, group *Group) {
+		if r < 0 || r >= rows || c < 0 || c >= cols || visited[r][c] || colors[r][c] != target {
+			return
+		}
+		visited[r][c] = true
+		group.Coordinates = append(group.Coordinates, Coordinate{R: r, C: c})
+		group.MinR, group.MaxR = min(group.MinR, r), max(group.MaxR, r)
+		group.MinC, group.MaxC = min(group.MinC, c), max(group.MaxC, c)
+		dfs(r-1, c, target, group)
+		dfs(r+1, c, target, group)
+		dfs(r, c-1, target, group)
+		dfs(r, c+1, target, group)
+	}
+
+	total := 0
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if visited[r][c] || colors[r][c] == nil {
+				continue
+			}
+			group := Group{Cell: grid.At(r, c), MinR: r, MaxR: r, MinC: c, MaxC: c}
+			dfs(r, c, colors[r][c], &group)
+			if len(group.Coordinates) >= 2 {
+				total += rule.Calculate(group, grid)
+			}
+		}
+	}
+	return total
+}
+
+// referencePalette holds the colors of the cells of randomGrid.
+var referencePalette = []color.Color{color.Gray{Y: 1}, color.Gray{Y: 2}, color.Gray{Y: 3}}
+
+// TestIncrementalScorerMatchesReference swaps random items on random grids
+// and checks after every swap that the scorer and Score agree with
+// referenceScore.
+func TestIncrementalScorerMatchesReference(t *testing.T) {
+	for _, rs := range incrementalRules() {
+		t.Run(rs.Name, func(t *testing.T) {
+			f := func(seed int64) bool {
+				rng := rand.New(rand.NewSource(seed))
+				rows, cols := 2+rng.Intn(5), 2+rng.Intn(5)
+				grid := randomGrid(rng, rows, cols)
+				scorer := NewIncrementalScorer(grid, rs.Rule)
+				expected := referenceScore(grid, rs.Rule)
+				if scorer.Score() != expected || Score(grid, rs.Rule) != expected {
+					t.Logf("Initial score %d, Score %d, expected %d", scorer.Score(), Score(grid, rs.Rule), expected)
+					return false
+				}
+
+				for i := 0; i < 20; i++ {
+					r1, c1, r2, c2 := rng.Intn(rows), rng.Intn(cols), rng.Intn(rows), rng.Intn(cols)
+					grid.Swap(r1, c1, r2, c2)
+					expected := referenceScore(grid, rs.Rule)
+					if got, full := scorer.Swap(r1, c1, r2, c2), Score(grid, rs.Rule); got != expected || full != expected {
+						t.Logf("Swap %d of (%d, %d) and (%d, %d): score %d, Score %d, expected %d", i, r1, c1, r2, c2, got, full, expected)
+						return false
+					}
+				}
+				return true
+			}
+			if err := quick.Check(f, &quick.Config{MaxCount: 200}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestIncrementalScorerBreakdown checks after random swaps that the breakdown
+// of the scorer equals the one of the swapped grid.
+func TestIncrementalScorerBreakdown(t *testing.T) {
+	palette := []color.Color{color.Gray{Y: 1}, color.Gray{Y: 2}, color.Gray{Y: 3}, color.Gray{Y: 4}}
+	for _, rs := range incrementalRules() {
+		t.Run(rs.Name, func(t *testing.T) {
+			f := func(seed int64) bool {
+				rng := rand.New(rand.NewSource(seed))
+				rows, cols := 2+rng.Intn(5), 2+rng.Intn(5)
+				grid := randomGrid(rng, rows, cols)
+				scorer := NewIncrementalScorer(grid, rs.Rule)
+				for i := 0; i < 5; i++ {
+					r1, c1, r2, c2 := rng.Intn(rows), rng.Intn(cols), rng.Intn(rows), rng.Intn(cols)
+					grid.Swap(r1, c1, r2, c2)
+					scorer.Swap(r1, c1, r2, c2)
+					if got, expected := scorer.Breakdown(palette), Breakdown(grid, palette, rs.Rule); !reflect.DeepEqual(got, expected) {
+						t.Logf("Swap %d of (%d, %d) and (%d, %d): breakdown %v, expected %v", i, r1, c1, r2, c2, got, expected)
+						return false
+					}
+				}
+				return true
+			}
+			if err := quick.Check(f, &quick.Config{MaxCount: 100}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestIncrementalScorerSwapDelta checks that the delta of a swap equals the
+// difference of the reference scores before and after it, and that the scorer is
+// left unchanged.
+func TestIncrementalScorerSwapDelta(t *testing.T) {
+	for _, rs := range incrementalRules() {
+		t.Run(rs.Name, func(t *testing.T) {
+			f := func(seed int64) bool {
+				rng := rand.New(rand.NewSource(seed))
+				grid := randomGrid(rng, 4, 4)
+				scorer := NewIncrementalScorer(grid, rs.Rule)
+				before := scorer.Score()
+
+				r1, c1, r2, c2 := rng.Intn(4), rng.Intn(4), rng.Intn(4), rng.Intn(4)
+				delta := scorer.SwapDelta(r1, c1, r2, c2)
+				if scorer.Score() != before {
+					t.Logf("SwapDelta changed the score from %d to %d", before, scorer.Score())
+					return false
+				}
+
+				reference := referenceScore(grid, rs.Rule)
+				grid.Swap(r1, c1, r2, c2)
+				if expected := referenceScore(grid, rs.Rule) - reference; delta != expected {
+					t.Logf("Delta of swapping (%d, %d) and (%d, %d) is %d, expected %d", r1, c1, r2, c2, delta, expected)
+					return false
+				}
+				return true
+			}
+			if err := quick.Check(f, &quick.Config{MaxCount: 500}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestIncrementalScorerCopiesGrid checks that the scorer does not modify the
+// grid it was created from.
+func TestIncrementalScorerCopiesGrid(t *testing.T) {
+	red, blue := tiles.CellOf(0), tiles.CellOf(1)
+	grid := gridOf([][]tiles.Cell{
+		{red, blue},
+		{blue, red},
+	})
+	scorer := NewIncrementalScorer(grid, StandardRuleSet{})
+	if score := scorer.Swap(0, 1, 1, 1); score != 4 {
+		t.Errorf("Expected a score of 4 after the swap, got %d", score)
+	}
+	if grid.At(0, 1) != blue || grid.At(1, 1) != red {
+		t.Error("Expected the original grid to be unchanged")
+	}
+}
+
+func BenchmarkIncrementalScorerSwapDelta(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	grid := randomGrid(rng, 10, 10)
+	scorer := NewIncrementalScorer(grid, StandardRuleSet{})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		scorer.SwapDelta(i%10, (i/10)%10, (i/100)%10, (i/1000)%10)
+	}
+}
+
+func BenchmarkScore(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	grid := randomGrid(rng, 10, 10)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Score(grid, StandardRuleSet{})
+	}
+}
@@ -0,0 +1,60 @@
+package scoring
+
+import "fmt"
+
+// DefaultRuleSetID identifies the rule set that is used unless another one is selected.
+const DefaultRuleSetID = "standard"
+
+// RuleSet is a named set of scoring rules that can be selected for a game.
+type RuleSet struct {
+	ID   string // Stable identifier, e.g. for share codes. Must not change once released.
+	Name string // Human-readable name
+	Rule ScoringRule
+}
+
+// registry holds the registered rule sets in the order they are offered to the player.
+var registry = []RuleSet{
+	{ID: DefaultRuleSetID, Name: "Standard", Rule: StandardRuleSet{}},
+	{ID: "compact", Name: "Compactness", Rule: CompactnessRuleSet{}},
+	{ID: "shapes", Name: "Target shapes", Rule: ShapeRuleSet{AllowTransforms: true}},
+	{ID: "shapes-fixed", Name: "Fixed target shapes", Rule: ShapeRuleSet{}},
+}
+
+// Register adds a rule set to the registry. It returns an error if the id is
+// empty or already taken.
+func Register(rs RuleSet) error {
+	if rs.ID == "" || rs.Rule == nil {
+		return fmt.Errorf("scoring: rule set %q needs an id and rules", rs.Name)
+	}
+	if _, ok := LookupRuleSet(rs.ID); ok {
+		return fmt.Errorf("scoring: rule set id %q is already registered", rs.ID)
+	}
+	registry = append(registry, rs)
+	return nil
+}
+
+// RuleSets returns all registered rule sets.
+func RuleSets() []RuleSet {
+	return append([]RuleSet(nil), registry...)
+}
+
+// LookupRuleSet returns the rule set with the given id.
+func LookupRuleSet(id string) (RuleSet, bool) {
+	for _, rs := range registry {
+		if rs.ID == id {
+			return rs, true
+		}
+	}
+	return RuleSet{}, false
+}
+
+// NextRuleSet returns the id of the rule set registered after the given one,
+// wrapping around after the last one.
+func NextRuleSet(id string) string {
+	for i, rs := range registry {
+		if rs.ID == id {
+			return registry[(i+1)%len(registry)].ID
+		}
+	}
+	return DefaultRuleSetID
+}
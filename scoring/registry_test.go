@@ -0,0 +1,50 @@
+package scoring
+
+import (
+	"testing"
+	"zenmojo/tiles"
+)
+
+func TestRuleSetRegistry(t *testing.T) {
+	rs, ok := LookupRuleSet(DefaultRuleSetID)
+	if !ok || rs.Rule != (StandardRuleSet{}) {
+		t.Fatalf("Expected the default rule set to be the standard rules, got %+v", rs)
+	}
+
+	// Cycling through the rule sets visits every one of them once.
+	seen := make(map[string]bool)
+	id := DefaultRuleSetID
+	for range RuleSets() {
+		seen[id] = true
+		id = NextRuleSet(id)
+	}
+	if id != DefaultRuleSetID || len(seen) != len(RuleSets()) {
+		t.Errorf("Expected cycling to visit all %d rule sets, visited %d", len(RuleSets()), len(seen))
+	}
+
+	if err := Register(RuleSet{ID: DefaultRuleSetID, Name: "Duplicate", Rule: StandardRuleSet{}}); err == nil {
+		t.Error("Expected an error when registering a duplicate id")
+	}
+	if err := Register(RuleSet{Name: "No id", Rule: StandardRuleSet{}}); err == nil {
+		t.Error("Expected an error when registering a rule set without an id")
+	}
+}
+
+func TestRegisteredRuleSetsReachTheirMaximum(t *testing.T) {
+	red := tiles.CellOf(0)
+	blue := tiles.CellOf(1)
+	// Optimal rectangles for both colors
+	grid := gridOf([][]tiles.Cell{
+		{red, red, blue, blue},
+		{red, red, blue, blue},
+	})
+
+	for _, rs := range RuleSets() {
+		t.Run(rs.Name, func(t *testing.T) {
+			score := Score(grid, rs.Rule)
+			if maxScore := rs.Rule.MaxPossibleScore(grid); score != maxScore {
+				t.Errorf("Expected a solved board to score the maximum of %d, got %d", maxScore, score)
+			}
+		})
+	}
+}
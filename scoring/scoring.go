@@ -1,11 +1,31 @@
 package scoring
 
-import "image/color"
+import (
+	"image/color"
+	"zenmojo/tiles"
+)
 
 // ScoringRule defines the contract for a set of scoring rules.
 // This allows for different scoring strategies (e.g., for different game modes).
 type ScoringRule interface {
-	Calculate(group Group, grid [][]color.Color) int
+	Calculate(group Group, grid tiles.Grid) int
+	// MaxPossibleScore returns the highest score the board can reach under these rules.
+	MaxPossibleScore(grid tiles.Grid) int
+}
+
+// countedRule is implemented by rule sets that can score a group when the
+// number of items of its color is already known. This saves the scan of the
+// whole grid that Calculate needs to count them.
+type countedRule interface {
+	calculateCounted(group Group, totalItems int, grid tiles.Grid) int
+}
+
+// calculateGroup scores a group, using the count of its color's items if the rule supports it.
+func calculateGroup(rule ScoringRule, group Group, totalItems int, grid tiles.Grid) int {
+	if counted, ok := rule.(countedRule); ok {
+		return counted.calculateCounted(group, totalItems, grid)
+	}
+	return rule.Calculate(group, grid)
 }
 
 // StandardRuleSet implements the default scoring logic where only
@@ -13,10 +33,14 @@ type ScoringRule interface {
 type StandardRuleSet struct{}
 
 // Calculate applies the standard scoring rules to a single group.
-func (s StandardRuleSet) Calculate(group Group, grid [][]color.Color) int {
-	// This is the logic from the old calculateScoreForGroup function.
-	// We will add the new "solid rectangle" rule here in a later step.
-	totalItemsOnBoard := totalColorItems(group.Color, grid)
+func (s StandardRuleSet) Calculate(group Group, grid tiles.Grid) int {
+	return s.calculateCounted(group, grid.Count(group.Cell), grid)
+}
+
+// calculateCounted scores a group whose tile has totalItemsOnBoard items on
+// the board: lines score their length and solid rectangles their size times
+// their area, but only if the group holds every item of its tile.
+func (s StandardRuleSet) calculateCounted(group Group, totalItemsOnBoard int, grid tiles.Grid) int {
 	if len(group.Coordinates) != totalItemsOnBoard {
 		return 0 // Only score groups that contain all items of that color.
 	}
@@ -43,6 +67,12 @@ func (s StandardRuleSet) Calculate(group Group, grid [][]color.Color) int {
 	return numItems * width * height
 }
 
+// MaxPossibleScore returns the score of a board where every color forms its
+// optimal rectangle.
+func (s StandardRuleSet) MaxPossibleScore(grid tiles.Grid) int {
+	return MaxScore(grid)
+}
+
 // Coordinate represents a position on the grid.
 type Coordinate struct {
 	R, C int
@@ -50,7 +80,7 @@ type Coordinate struct {
 
 // Group represents a contiguous group of same-colored items.
 type Group struct {
-	Color       color.Color
+	Cell        tiles.Cell // The tile of all items in the group
 	Coordinates []Coordinate
 	MinR, MaxR  int // Bounding box for shape detection
 	MinC, MaxC  int // Bounding box for shape detection
@@ -58,31 +88,41 @@ type Group struct {
 
 // CalculateScore analyzes the entire grid using a given rule set and returns the total score.
 func CalculateScore(grid [][]color.Color, rule ScoringRule) int {
-	groups := findGroups(grid)
+	cells, _ := tiles.FromColors(grid, nil)
+	return Score(cells, rule)
+}
+
+// Score is CalculateScore for a grid of cells.
+func Score(grid tiles.Grid, rule ScoringRule) int {
+	counts := grid.Counts()
 	totalScore := 0
-	for _, group := range groups {
-		totalScore += rule.Calculate(group, grid)
+	for _, group := range collectGroups(grid, 2) {
+		totalScore += calculateGroup(rule, group, counts[group.Cell], grid)
 	}
 	return totalScore
 }
 
 func findGroups(grid [][]color.Color) []Group {
-	rows, cols := len(grid), len(grid[0])
-	visited := make([][]bool, rows)
-	for i := range visited {
-		visited[i] = make([]bool, cols)
-	}
+	cells, _ := tiles.FromColors(grid, nil)
+	return collectGroups(cells, 2)
+}
+
+// collectGroups returns all contiguous groups with at least minSize items, in
+// the order of their first cell in row-major order.
+func collectGroups(grid tiles.Grid, minSize int) []Group {
+	visited := make([]int, len(grid.Cells))
+	const pass = 1
 
 	var groups []Group
 
-	for r := 0; r < rows; r++ {
-		for c := 0; c < cols; c++ {
-			if visited[r][c] || grid[r][c] == nil {
+	for r := 0; r < grid.Height; r++ {
+		for c := 0; c < grid.Width; c++ {
+			if visited[r*grid.Width+c] == pass || grid.At(r, c) == tiles.Empty {
 				continue
 			}
 
 			currentGroup := Group{
-				Color:       grid[r][c],
+				Cell:        grid.At(r, c),
 				Coordinates: []Coordinate{},
 				MinR:        r,
 				MaxR:        r,
@@ -90,9 +130,9 @@ func findGroups(grid [][]color.Color) []Group {
 				MaxC:        c,
 			}
 
-			dfs(r, c, grid[r][c], &currentGroup, visited, grid)
+			flood(grid, visited, pass, r, c, grid.At(r, c), &currentGroup)
 
-			if len(currentGroup.Coordinates) >= 2 {
+			if len(currentGroup.Coordinates) >= minSize {
 				groups = append(groups, currentGroup)
 			}
 		}
@@ -100,13 +140,19 @@ func findGroups(grid [][]color.Color) []Group {
 	return groups
 }
 
-func dfs(r, c int, targetColor color.Color, currentGroup *Group, visited [][]bool, grid [][]color.Color) {
-	rows, cols := len(grid), len(grid[0])
-	if r < 0 || r >= rows || c < 0 || c >= cols || visited[r][c] || !colorsEqual(grid[r][c], targetColor) {
+// flood adds all cells connected to (r, c) with the target cell value to the
+// group. A cell counts as visited if its entry in visited equals pass, so that
+// the same slice can be reused for many searches without clearing it.
+func flood(cells tiles.Grid, visited []int, pass, r, c int, target tiles.Cell, currentGroup *Group) {
+	if r < 0 || r >= cells.Height || c < 0 || c >= cells.Width {
+		return
+	}
+	i := r*cells.Width + c
+	if visited[i] == pass || cells.Cells[i] != target {
 		return
 	}
 
-	visited[r][c] = true
+	visited[i] = pass
 	currentGroup.Coordinates = append(currentGroup.Coordinates, Coordinate{R: r, C: c})
 
 	if r < currentGroup.MinR {
@@ -122,95 +168,67 @@ func dfs(r, c int, targetColor color.Color, currentGroup *Group, visited [][]boo
 		currentGroup.MaxC = c
 	}
 
-	directions := []struct{ dr, dc int }{
-		{-1, 0}, // Up
-		{1, 0},  // Down
-		{0, -1}, // Left
-		{0, 1},  // Right
-	}
-	for _, dir := range directions {
-		dfs(r+dir.dr, c+dir.dc, targetColor, currentGroup, visited, grid)
-	}
-}
-
-func colorsEqual(c1, c2 color.Color) bool {
-	if c1 == nil && c2 == nil {
-		return true
-	}
-	if c1 == nil || c2 == nil {
-		return false
-	}
-
-	r1, g1, b1, a1 := c1.RGBA()
-	r2, g2, b2, a2 := c2.RGBA()
-	return r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2
-}
-
-func totalColorItems(color color.Color, grid [][]color.Color) int {
-	count := 0
-	for r := range grid {
-		for c := range grid[r] {
-			if colorsEqual(grid[r][c], color) {
-				count++
-			}
-		}
-	}
-	return count
+	flood(cells, visited, pass, r-1, c, target, currentGroup) // Up
+	flood(cells, visited, pass, r+1, c, target, currentGroup) // Down
+	flood(cells, visited, pass, r, c-1, target, currentGroup) // Left
+	flood(cells, visited, pass, r, c+1, target, currentGroup) // Right
 }
 
 // CalculateMaxPossibleScore determines the theoretical maximum score for a given board layout.
 // It does this by counting the items of each color and calculating the score for the
 // most optimal shape (the most "square-like" rectangle) that can be formed with that number of items.
 func CalculateMaxPossibleScore(grid [][]color.Color) int {
-	colorCounts := make(map[color.Color]int)
-	for r := range grid {
-		for c := range grid[r] {
-			if grid[r][c] != nil {
-				colorCounts[grid[r][c]]++
-			}
-		}
-	}
+	cells, _ := tiles.FromColors(grid, nil)
+	return MaxScore(cells)
+}
 
+// MaxScore is CalculateMaxPossibleScore for a grid of cells.
+func MaxScore(grid tiles.Grid) int {
 	totalMaxScore := 0
-	for _, numItems := range colorCounts {
+	for _, numItems := range grid.Counts() {
 		if numItems < 2 {
 			continue
 		}
+		_, _, bestScoreForColor := OptimalShape(numItems)
+		totalMaxScore += bestScoreForColor
+	}
+	return totalMaxScore
+}
+
+// OptimalShape returns the dimensions and score of the best shape that can be formed
+// with the given number of items under the standard rules. The width is never larger
+// than the height; rotating the shape does not change its score.
+func OptimalShape(numItems int) (width, height, score int) {
+	width, height = 1, numItems
+	// Find all factors to determine possible rectangle shapes
+	for w := 1; w*w <= numItems; w++ {
+		if numItems%w == 0 {
+			h := numItems / w
+			var s int
+			// Apply the correct scoring rule based on the shape.
+			if w == 1 || h == 1 {
+				// For a line shape, the score is just the number of items.
+				s = numItems
+			} else {
+				// For a solid rectangle, the score is items * width * height.
+				s = numItems * w * h
+			}
 
-		bestScoreForColor := 0
-		// Find all factors to determine possible rectangle shapes
-		for w := 1; w*w <= numItems; w++ {
-			if numItems%w == 0 {
-				h := numItems / w
-				var score int
-				// Apply the correct scoring rule based on the shape.
-				if w == 1 || h == 1 {
-					// For a line shape, the score is just the number of items.
-					score = numItems
-				} else {
-					// For a solid rectangle, the score is items * width * height.
-					score = numItems * w * h
-				}
-
-				if score > bestScoreForColor {
-					bestScoreForColor = score
-				}
+			if s > score {
+				width, height, score = w, h, s
 			}
 		}
-		totalMaxScore += bestScoreForColor
 	}
-	return totalMaxScore
+	return width, height, score
 }
 
 // CountColors counts the number of tiles for each color on the grid.
 func CountColors(grid [][]color.Color) map[color.Color]int {
-	colorCounts := make(map[color.Color]int)
-	for r := range grid {
-		for c := range grid[r] {
-			if grid[r][c] != nil {
-				colorCounts[grid[r][c]]++
-			}
-		}
+	cells, palette := tiles.FromColors(grid, nil)
+	counts := cells.Counts()
+	colorCounts := make(map[color.Color]int, len(palette))
+	for i, c := range palette {
+		colorCounts[c] = counts[tiles.CellOf(i)]
 	}
 	return colorCounts
 }
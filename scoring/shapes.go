@@ -0,0 +1,261 @@
+package scoring
+
+import (
+	"sort"
+	"zenmojo/tiles"
+)
+
+// Shape is a polyomino, given by the cells it covers relative to the top-left
+// corner of its bounding box.
+type Shape struct {
+	Name  string
+	Cells []Coordinate
+}
+
+// NewShape creates a shape from rows of text, where 'X' marks a covered cell.
+func NewShape(name string, rows ...string) Shape {
+	s := Shape{Name: name}
+	for r, row := range rows {
+		for c, ch := range row {
+			if ch == 'X' {
+				s.Cells = append(s.Cells, Coordinate{R: r, C: c})
+			}
+		}
+	}
+	return s.normalized()
+}
+
+// ShapeCatalog lists the shapes that can be required in target-shape puzzles.
+// The position of a shape in the catalog identifies it in share codes, so new
+// shapes must only be appended.
+var ShapeCatalog = []Shape{
+	NewShape("Line", "XX"),
+	NewShape("Line", "XXX"),
+	NewShape("Corner", "X.", "XX"),
+	NewShape("Line", "XXXX"),
+	NewShape("Square", "XX", "XX"),
+	NewShape("L", "X.", "X.", "XX"),
+	NewShape("T", "XXX", ".X."),
+	NewShape("S", ".XX", "XX."),
+	NewShape("Line", "XXXXX"),
+	NewShape("L", "X.", "X.", "X.", "XX"),
+	NewShape("V", "X..", "X..", "XXX"),
+	NewShape("T", "XXX", ".X.", ".X."),
+	NewShape("Plus", ".X.", "XXX", ".X."),
+	NewShape("U", "X.X", "XXX"),
+	NewShape("P", "XX", "XX", "X."),
+	NewShape("Rectangle", "XXX", "XXX"),
+	NewShape("L", "X.", "X.", "X.", "X.", "XX"),
+	NewShape("Arch", "XXX", "X.X", "X.X"),
+	NewShape("V", "X...", "X...", "X...", "XXXX"),
+	NewShape("T", "XXXXX", "..X..", "..X.."),
+	NewShape("Rectangle", "XXXX", "XXXX"),
+	NewShape("Square", "XXX", "XXX", "XXX"),
+	NewShape("Rectangle", "XXXXX", "XXXXX"),
+}
+
+// ShapeID returns a number that identifies a catalog shape in a specific
+// orientation, e.g. for share codes. ok is false if the shape is not in the catalog.
+func ShapeID(s Shape) (id int, ok bool) {
+	for i, catalogShape := range ShapeCatalog {
+		for v, variant := range catalogShape.Variants() {
+			if variant.equal(s.normalized()) {
+				return i*8 + v, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// ShapeFromID returns the shape identified by ShapeID.
+func ShapeFromID(id int) (Shape, bool) {
+	if id < 0 || id/8 >= len(ShapeCatalog) {
+		return Shape{}, false
+	}
+	variants := ShapeCatalog[id/8].Variants()
+	if id%8 >= len(variants) {
+		return Shape{}, false
+	}
+	return variants[id%8], true
+}
+
+// Size returns the number of cells the shape covers.
+func (s Shape) Size() int {
+	return len(s.Cells)
+}
+
+// Bounds returns the width and height of the shape's bounding box.
+func (s Shape) Bounds() (width, height int) {
+	for _, cell := range s.Cells {
+		if cell.C+1 > width {
+			width = cell.C + 1
+		}
+		if cell.R+1 > height {
+			height = cell.R + 1
+		}
+	}
+	return width, height
+}
+
+// Points returns the score of a group that forms this shape. Like in the
+// standard rules, lines score their length and all other shapes score
+// items * width * height of their bounding box.
+func (s Shape) Points() int {
+	width, height := s.Bounds()
+	if width == 1 || height == 1 {
+		return s.Size()
+	}
+	return s.Size() * width * height
+}
+
+// Variants returns all distinct rotations and reflections of the shape.
+// The shape itself is always the first variant.
+func (s Shape) Variants() []Shape {
+	variants := []Shape{s}
+	current := s
+	for i := 0; i < 8; i++ {
+		if i == 4 {
+			current = current.reflected()
+		} else if i > 0 {
+			current = current.rotated()
+		}
+		isNew := true
+		for _, v := range variants {
+			if v.equal(current) {
+				isNew = false
+				break
+			}
+		}
+		if isNew {
+			variants = append(variants, current)
+		}
+	}
+	return variants
+}
+
+// Matches reports whether the group covers exactly the cells of the shape.
+// If allowTransforms is set, rotated and reflected versions of the shape match as well.
+func (s Shape) Matches(group Group, allowTransforms bool) bool {
+	if len(group.Coordinates) != s.Size() {
+		return false
+	}
+	cells := make([]Coordinate, len(group.Coordinates))
+	for i, cell := range group.Coordinates {
+		cells[i] = Coordinate{R: cell.R - group.MinR, C: cell.C - group.MinC}
+	}
+	groupShape := Shape{Cells: cells}.normalized()
+
+	candidates := []Shape{s}
+	if allowTransforms {
+		candidates = s.Variants()
+	}
+	for _, candidate := range candidates {
+		if candidate.equal(groupShape) {
+			return true
+		}
+	}
+	return false
+}
+
+// rotated returns the shape rotated by 90 degrees clockwise.
+func (s Shape) rotated() Shape {
+	_, height := s.Bounds()
+	cells := make([]Coordinate, len(s.Cells))
+	for i, cell := range s.Cells {
+		cells[i] = Coordinate{R: cell.C, C: height - 1 - cell.R}
+	}
+	return Shape{Name: s.Name, Cells: cells}.normalized()
+}
+
+// reflected returns the shape mirrored along its vertical axis.
+func (s Shape) reflected() Shape {
+	width, _ := s.Bounds()
+	cells := make([]Coordinate, len(s.Cells))
+	for i, cell := range s.Cells {
+		cells[i] = Coordinate{R: cell.R, C: width - 1 - cell.C}
+	}
+	return Shape{Name: s.Name, Cells: cells}.normalized()
+}
+
+// normalized moves the shape to the origin and sorts its cells in row-major order.
+func (s Shape) normalized() Shape {
+	if len(s.Cells) == 0 {
+		return s
+	}
+	minR, minC := s.Cells[0].R, s.Cells[0].C
+	for _, cell := range s.Cells {
+		if cell.R < minR {
+			minR = cell.R
+		}
+		if cell.C < minC {
+			minC = cell.C
+		}
+	}
+	cells := make([]Coordinate, len(s.Cells))
+	for i, cell := range s.Cells {
+		cells[i] = Coordinate{R: cell.R - minR, C: cell.C - minC}
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].R != cells[j].R {
+			return cells[i].R < cells[j].R
+		}
+		return cells[i].C < cells[j].C
+	})
+	return Shape{Name: s.Name, Cells: cells}
+}
+
+// equal reports whether two normalized shapes cover the same cells.
+func (s Shape) equal(other Shape) bool {
+	if len(s.Cells) != len(other.Cells) {
+		return false
+	}
+	for i := range s.Cells {
+		if s.Cells[i] != other.Cells[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ShapeRuleSet implements the target-shape rules: every color has a required
+// shape and only scores if its group forms exactly that shape.
+type ShapeRuleSet struct {
+	Shapes          map[tiles.Cell]Shape
+	AllowTransforms bool // Whether rotated and reflected shapes count as well
+}
+
+// Calculate applies the target-shape rules to a single group. Colors without
+// a required shape are scored by the standard rules.
+func (s ShapeRuleSet) Calculate(group Group, grid tiles.Grid) int {
+	return s.calculateCounted(group, grid.Count(group.Cell), grid)
+}
+
+func (s ShapeRuleSet) calculateCounted(group Group, totalItems int, grid tiles.Grid) int {
+	shape, ok := s.Shapes[group.Cell]
+	if !ok {
+		return StandardRuleSet{}.calculateCounted(group, totalItems, grid)
+	}
+
+	if len(group.Coordinates) != totalItems {
+		return 0 // Only score groups that contain all items of that color.
+	}
+	if !shape.Matches(group, s.AllowTransforms) {
+		return 0
+	}
+	return shape.Points()
+}
+
+// MaxPossibleScore returns the score of a board where every color forms its
+// required shape.
+func (s ShapeRuleSet) MaxPossibleScore(grid tiles.Grid) int {
+	total := 0
+	for cell, numItems := range grid.Counts() {
+		if shape, ok := s.Shapes[tiles.Cell(cell)]; ok && shape.Size() == numItems {
+			total += shape.Points()
+		} else if numItems >= 2 {
+			_, _, score := OptimalShape(numItems)
+			total += score
+		}
+	}
+	return total
+}
@@ -0,0 +1,167 @@
+package scoring
+
+import (
+	"testing"
+	"zenmojo/tiles"
+)
+
+func TestShapeVariants(t *testing.T) {
+	testCases := []struct {
+		shape            Shape
+		expectedVariants int
+	}{
+		{NewShape("Square", "XX", "XX"), 1},
+		{NewShape("Line", "XXXX"), 2},
+		{NewShape("T", "XXX", ".X."), 4},
+		{NewShape("S", ".XX", "XX."), 4},
+		{NewShape("L", "X.", "X.", "XX"), 8},
+		{NewShape("Plus", ".X.", "XXX", ".X."), 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.shape.Name, func(t *testing.T) {
+			variants := tc.shape.Variants()
+			if len(variants) != tc.expectedVariants {
+				t.Errorf("Expected %d variants, got %d", tc.expectedVariants, len(variants))
+			}
+			for _, v := range variants {
+				if v.Size() != tc.shape.Size() {
+					t.Errorf("Variant has %d cells, expected %d", v.Size(), tc.shape.Size())
+				}
+			}
+		})
+	}
+}
+
+func TestShapeCatalogFitsTheBoard(t *testing.T) {
+	for i, shape := range ShapeCatalog {
+		width, height := shape.Bounds()
+		if width > 10 || height > 10 {
+			t.Errorf("Shape %d (%s) is too large: %dx%d", i, shape.Name, width, height)
+		}
+		if shape.Size() < 2 || shape.Size() > 10 {
+			t.Errorf("Shape %d (%s) has invalid size %d", i, shape.Name, shape.Size())
+		}
+	}
+}
+
+func TestShapeRuleSet_Calculate(t *testing.T) {
+	red := tiles.CellOf(0)
+	blue := tiles.CellOf(1)
+	empty := tiles.Empty
+
+	lShape := NewShape("L", "X.", "X.", "XX")
+
+	testCases := []struct {
+		name            string
+		grid            [][]tiles.Cell
+		allowTransforms bool
+		expectedScore   int
+	}{
+		{
+			name: "Group in the required shape scores",
+			grid: [][]tiles.Cell{
+				{red, empty},
+				{red, empty},
+				{red, red},
+			},
+			expectedScore: 24, // 4 items * 2 width * 3 height
+		},
+		{
+			name: "Rotated shape scores if transforms are allowed",
+			grid: [][]tiles.Cell{
+				{red, red, red},
+				{red, empty, empty},
+			},
+			allowTransforms: true,
+			expectedScore:   24,
+		},
+		{
+			name: "Rotated shape scores 0 if transforms are not allowed",
+			grid: [][]tiles.Cell{
+				{red, red, red},
+				{red, empty, empty},
+			},
+			expectedScore: 0,
+		},
+		{
+			name: "Rectangle scores 0 if an L is required",
+			grid: [][]tiles.Cell{
+				{red, red},
+				{red, red},
+			},
+			allowTransforms: true,
+			expectedScore:   0,
+		},
+		{
+			name: "Colors without a required shape use the standard rules",
+			grid: [][]tiles.Cell{
+				{blue, blue, blue},
+			},
+			expectedScore: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rules := ShapeRuleSet{
+				Shapes:          map[tiles.Cell]Shape{red: lShape},
+				AllowTransforms: tc.allowTransforms,
+			}
+			if actual := Score(gridOf(tc.grid), rules); actual != tc.expectedScore {
+				t.Errorf("Expected score %d, but got %d", tc.expectedScore, actual)
+			}
+		})
+	}
+}
+
+func TestShapeRuleSet_MaxPossibleScore(t *testing.T) {
+	red := tiles.CellOf(0)
+	blue := tiles.CellOf(1)
+	grid := gridOf([][]tiles.Cell{
+		{red, red, blue},
+		{red, red, blue},
+	})
+
+	if actual := (StandardRuleSet{}).MaxPossibleScore(grid); actual != 18 {
+		t.Errorf("Expected a standard max score of 18, got %d", actual)
+	}
+
+	rules := ShapeRuleSet{Shapes: map[tiles.Cell]Shape{red: NewShape("T", "XXX", ".X.")}}
+	if actual := rules.MaxPossibleScore(grid); actual != 26 {
+		t.Errorf("Expected a target-shape max score of 26, got %d", actual)
+	}
+}
+
+func TestShapeIDRoundTrip(t *testing.T) {
+	for _, shape := range ShapeCatalog {
+		for _, variant := range shape.Variants() {
+			id, ok := ShapeID(variant)
+			if !ok {
+				t.Fatalf("Expected %s to be in the catalog", shape.Name)
+			}
+			decoded, ok := ShapeFromID(id)
+			if !ok || !decoded.equal(variant) {
+				t.Errorf("Shape %s with id %d did not survive the round trip", shape.Name, id)
+			}
+		}
+	}
+
+	if _, ok := ShapeID(NewShape("Unknown", "XXXXXXXXXXX")); ok {
+		t.Error("Expected a shape outside the catalog to have no id")
+	}
+	if _, ok := ShapeFromID(len(ShapeCatalog) * 8); ok {
+		t.Error("Expected an id past the catalog to be invalid")
+	}
+}
+
+// gridOf builds a grid from rows of cells.
+func gridOf(rows [][]tiles.Cell) tiles.Grid {
+	grid := tiles.New(len(rows[0]), len(rows))
+	for r, row := range rows {
+		for c, cell := range row {
+			grid.Set(r, c, cell)
+		}
+	}
+	return grid
+}
@@ -3,79 +3,228 @@ package sharing
 import (
 	"errors"
 	"image/color"
+	"strconv"
 	"strings"
 	"zenmojo/config"
+	"zenmojo/tiles"
 )
 
 // Define the character set for encoding. Using a URL-safe set is good practice.
 const encodingChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
 
-var (
-	colorToChar   map[string]rune
-	charToColor   map[rune]color.Color
-	isInitialized = false
-)
+// optionSeparator separates the optional game settings from the board layout.
+const optionSeparator = "~"
 
-// initialize prepares the mapping tables. This is done once.
-func initialize() {
-	if isInitialized || len(config.Palette) > len(encodingChars) {
-		return
-	}
-	colorToChar = make(map[string]rune)
-	charToColor = make(map[rune]color.Color)
+// emptyChar encodes an empty cell, e.g. the gap in the sliding-puzzle variant.
+const emptyChar = '.'
 
-	for i, c := range config.Palette {
-		// Use a string representation of the color as a map key
-		r, g, b, a := c.RGBA()
-		key := string(r) + string(g) + string(b) + string(a)
+// Options holds the game settings that are shared together with a board, so that
+// everyone plays it under the same conditions. Zero values are left out of the code.
+type Options struct {
+	SwapMode int    // The board.SwapMode the board is played with
+	Budget   int    // The move budget of a challenge, or 0 for an unlimited game
+	Rules    string // The id of the scoring rule set, or "" for the default rules
 
-		char := rune(encodingChars[i])
-		colorToChar[key] = char
-		charToColor[char] = c
-	}
-	isInitialized = true
+	// Shapes holds the required shape of each color in a target-shape puzzle,
+	// identified by scoring.ShapeID.
+	Shapes map[tiles.Cell]int
 }
 
 // Encode takes a board grid and converts it into a shareable string code.
 func Encode(grid [][]color.Color) (string, error) {
-	initialize()
-	if !isInitialized {
+	cells, palette := tiles.FromColors(grid, config.Palette)
+	if len(palette) != len(config.Palette) {
+		return "", errors.New("sharing: color not found in palette")
+	}
+	return EncodeTiles(cells)
+}
+
+// EncodeTiles converts a board of cells, whose palette is config.Palette, into
+// a shareable string code. Every cell is written as the character at its
+// palette index.
+func EncodeTiles(cells tiles.Grid) (string, error) {
+	if len(config.Palette) > len(encodingChars) {
 		return "", errors.New("sharing: palette size exceeds encoding character set")
 	}
+	if cells.Width != config.GridSize || cells.Height != config.GridSize {
+		return "", errors.New("sharing: invalid grid size")
+	}
 
 	var sb strings.Builder
-	sb.Grow(config.GridSize * config.GridSize)
+	sb.Grow(len(cells.Cells))
+	for _, cell := range cells.Cells {
+		if cell == tiles.Empty {
+			sb.WriteRune(emptyChar)
+			continue
+		}
+		if cell.PaletteIndex() >= len(config.Palette) {
+			return "", errors.New("sharing: color not found in palette")
+		}
+		sb.WriteByte(encodingChars[cell.PaletteIndex()])
+	}
+	return sb.String(), nil
+}
+
+// EncodeWithOptions converts a board grid and its game settings into a shareable string code.
+// Settings are appended to the layout as "~" followed by a key letter and a value.
+func EncodeWithOptions(grid [][]color.Color, opts Options) (string, error) {
+	cells, palette := tiles.FromColors(grid, config.Palette)
+	if len(palette) != len(config.Palette) {
+		return "", errors.New("sharing: color not found in palette")
+	}
+	return EncodeTilesWithOptions(cells, opts)
+}
+
+// EncodeTilesWithOptions is EncodeWithOptions for a board of cells, whose
+// palette is config.Palette.
+func EncodeTilesWithOptions(cells tiles.Grid, opts Options) (string, error) {
+	code, err := EncodeTiles(cells)
+	if err != nil {
+		return "", err
+	}
+	if opts.SwapMode != 0 {
+		code += optionSeparator + "m" + strconv.Itoa(opts.SwapMode)
+	}
+	if opts.Budget != 0 {
+		code += optionSeparator + "b" + strconv.Itoa(opts.Budget)
+	}
+	if opts.Rules != "" {
+		if strings.Contains(opts.Rules, optionSeparator) {
+			return "", errors.New("sharing: invalid rule set id")
+		}
+		code += optionSeparator + "r" + opts.Rules
+	}
+	if len(opts.Shapes) > 0 {
+		shapes, err := encodeShapes(opts.Shapes)
+		if err != nil {
+			return "", err
+		}
+		code += optionSeparator + "s" + shapes
+	}
+	return code, nil
+}
 
-	for r := 0; r < config.GridSize; r++ {
-		for c := 0; c < config.GridSize; c++ {
-			cellColor := grid[r][c]
-			r, g, b, a := cellColor.RGBA()
-			key := string(r) + string(g) + string(b) + string(a)
+// DecodeWithOptions takes a shareable code and converts it back into a board grid and its game settings.
+func DecodeWithOptions(code string) ([][]color.Color, Options, error) {
+	cells, opts, err := DecodeTilesWithOptions(code)
+	if err != nil {
+		return nil, Options{}, err
+	}
+	return cells.Colors(config.Palette), opts, nil
+}
 
-			char, ok := colorToChar[key]
-			if !ok {
-				return "", errors.New("sharing: color not found in palette")
+// DecodeTilesWithOptions is DecodeWithOptions for a board of cells, whose
+// palette is config.Palette.
+func DecodeTilesWithOptions(code string) (tiles.Grid, Options, error) {
+	var opts Options
+	parts := strings.Split(strings.TrimSpace(code), optionSeparator)
+	for _, part := range parts[1:] {
+		if len(part) < 2 {
+			return tiles.Grid{}, Options{}, errors.New("sharing: invalid option in code")
+		}
+		if part[0] == 's' {
+			shapes, err := decodeShapes(part[1:])
+			if err != nil {
+				return tiles.Grid{}, Options{}, err
 			}
-			sb.WriteRune(char)
+			opts.Shapes = shapes
+			continue
+		}
+		if part[0] == 'r' {
+			opts.Rules = part[1:]
+			continue
+		}
+		value, err := strconv.Atoi(part[1:])
+		if err != nil || value < 0 {
+			return tiles.Grid{}, Options{}, errors.New("sharing: invalid option value in code")
+		}
+		switch part[0] {
+		case 'm':
+			opts.SwapMode = value
+		case 'b':
+			opts.Budget = value
+		}
+		// Unknown options are ignored so that codes from newer versions still load.
+	}
+
+	cells, err := DecodeTiles(parts[0])
+	if err != nil {
+		return tiles.Grid{}, Options{}, err
+	}
+	return cells, opts, nil
+}
+
+// encodeShapes writes the required shapes as groups of three characters:
+// the color, followed by the shape id in base 64. Colors are written in palette
+// order, so equal settings always produce the same code.
+func encodeShapes(shapes map[tiles.Cell]int) (string, error) {
+	var sb strings.Builder
+	for i := range config.Palette {
+		id, ok := shapes[tiles.CellOf(i)]
+		if !ok {
+			continue
+		}
+		if id < 0 || id >= len(encodingChars)*len(encodingChars) {
+			return "", errors.New("sharing: shape id out of range")
 		}
+		sb.WriteByte(encodingChars[i])
+		sb.WriteByte(encodingChars[id/len(encodingChars)])
+		sb.WriteByte(encodingChars[id%len(encodingChars)])
+	}
+	if sb.Len() != 3*len(shapes) {
+		return "", errors.New("sharing: color not found in palette")
 	}
 	return sb.String(), nil
 }
 
+// decodeShapes reads the required shapes written by encodeShapes.
+func decodeShapes(code string) (map[tiles.Cell]int, error) {
+	if len(code)%3 != 0 {
+		return nil, errors.New("sharing: invalid shape option in code")
+	}
+	shapes := make(map[tiles.Cell]int)
+	for i := 0; i < len(code); i += 3 {
+		index := strings.IndexByte(encodingChars, code[i])
+		high := strings.IndexByte(encodingChars, code[i+1])
+		low := strings.IndexByte(encodingChars, code[i+2])
+		if index < 0 || index >= len(config.Palette) || high < 0 || low < 0 {
+			return nil, errors.New("sharing: invalid shape option in code")
+		}
+		shapes[tiles.CellOf(index)] = high*len(encodingChars) + low
+	}
+	return shapes, nil
+}
+
 // Decode takes a shareable code and converts it back into a board grid.
+// Any game settings in the code are ignored.
 func Decode(code string) ([][]color.Color, error) {
-	initialize()
+	grid, _, err := DecodeWithOptions(code)
+	return grid, err
+}
+
+// DecodeTiles converts the layout part of a shareable code back into a board
+// of cells, whose palette is config.Palette. A board has at most one empty
+// cell, the gap of the sliding-puzzle variant.
+func DecodeTiles(code string) (tiles.Grid, error) {
 	if len(code) != config.GridSize*config.GridSize {
-		return nil, errors.New("sharing: invalid code length")
+		return tiles.Grid{}, errors.New("sharing: invalid code length")
 	}
 
-	grid := make([][]color.Color, config.GridSize)
-	for r := 0; r < config.GridSize; r++ {
-		grid[r] = make([]color.Color, config.GridSize)
-		for c := 0; c < config.GridSize; c++ {
-			char := rune(code[r*config.GridSize+c])
-			grid[r][c] = charToColor[char]
+	cells := tiles.New(config.GridSize, config.GridSize)
+	hasEmpty := false
+	for i := 0; i < len(code); i++ {
+		if code[i] == emptyChar {
+			if hasEmpty {
+				return tiles.Grid{}, errors.New("sharing: more than one empty cell in code")
+			}
+			hasEmpty = true
+			continue // Leave the cell empty
+		}
+		index := strings.IndexByte(encodingChars, code[i])
+		if index < 0 || index >= len(config.Palette) {
+			return tiles.Grid{}, errors.New("sharing: invalid character in code")
 		}
+		cells.Cells[i] = tiles.CellOf(index)
 	}
-	return grid, nil
+	return cells, nil
 }
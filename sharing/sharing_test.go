@@ -0,0 +1,134 @@
+package sharing
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+	"zenmojo/config"
+	"zenmojo/tiles"
+)
+
+// testGrid returns a full grid that uses every palette color.
+func testGrid() [][]color.Color {
+	grid := make([][]color.Color, config.GridSize)
+	for r := range grid {
+		grid[r] = make([]color.Color, config.GridSize)
+		for c := range grid[r] {
+			grid[r][c] = config.Palette[(r*config.GridSize+c)%len(config.Palette)]
+		}
+	}
+	return grid
+}
+
+func TestEncodeDecodeWithOptions(t *testing.T) {
+	grid := testGrid()
+	grid[4][7] = nil // An empty cell, as in the sliding-puzzle variant
+
+	testCases := []struct {
+		name string
+		opts Options
+	}{
+		{"no options", Options{}},
+		{"swap mode only", Options{SwapMode: 2}},
+		{"challenge budget", Options{SwapMode: 1, Budget: 57}},
+		{"rule set", Options{Rules: "compact"}},
+		{"target shapes", Options{Rules: "shapes", Shapes: map[tiles.Cell]int{tiles.CellOf(0): 3, tiles.CellOf(3): 130}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, err := EncodeWithOptions(grid, tc.opts)
+			if err != nil {
+				t.Fatalf("Unexpected error encoding: %v", err)
+			}
+			decoded, opts, err := DecodeWithOptions(code)
+			if err != nil {
+				t.Fatalf("Unexpected error decoding %q: %v", code, err)
+			}
+			if !reflect.DeepEqual(opts, tc.opts) {
+				t.Errorf("Expected options %+v, got %+v", tc.opts, opts)
+			}
+			for r := range grid {
+				for c := range grid[r] {
+					if decoded[r][c] != grid[r][c] {
+						t.Fatalf("Grid mismatch at position (%d, %d)", r, c)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsInvalidCodes(t *testing.T) {
+	valid, err := Encode(testGrid())
+	if err != nil {
+		t.Fatalf("Unexpected error encoding: %v", err)
+	}
+
+	invalidCodes := map[string]string{
+		"too short":         valid[1:],
+		"invalid character": "*" + valid[1:],
+		"two empty cells":   ".." + valid[2:],
+		"malformed option":  valid + "~b",
+		"negative budget":   valid + "~b-3",
+		"truncated shapes":  valid + "~sAB",
+		"invalid shape":     valid + "~sA*B",
+	}
+	for name, code := range invalidCodes {
+		if _, err := Decode(code); err == nil {
+			t.Errorf("%s: expected an error for code %q", name, code)
+		}
+	}
+
+	// Options from newer versions are ignored.
+	if _, err := Decode(valid + "~z9"); err != nil {
+		t.Errorf("Expected unknown options to be ignored, got %v", err)
+	}
+}
+
+func TestEncodeTilesMatchesEncode(t *testing.T) {
+	grid := testGrid()
+	code, err := Encode(grid)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	// Cells are written as the character at their palette index.
+	if code[:3] != "ABC" {
+		t.Errorf("Expected the code to start with \"ABC\", got %q", code[:3])
+	}
+
+	cells, err := DecodeTiles(code)
+	if err != nil {
+		t.Fatalf("DecodeTiles failed: %v", err)
+	}
+	tilesCode, err := EncodeTiles(cells)
+	if err != nil {
+		t.Fatalf("EncodeTiles failed: %v", err)
+	}
+	if tilesCode != code {
+		t.Errorf("Expected EncodeTiles to produce %q, got %q", code, tilesCode)
+	}
+	if !reflect.DeepEqual(cells.Colors(config.Palette), grid) {
+		t.Error("Expected the decoded cells to convert back to the original grid")
+	}
+
+	opts := Options{SwapMode: 1, Budget: 57, Rules: "compact"}
+	withOptions, err := EncodeWithOptions(grid, opts)
+	if err != nil {
+		t.Fatalf("EncodeWithOptions failed: %v", err)
+	}
+	tilesWithOptions, err := EncodeTilesWithOptions(cells, opts)
+	if err != nil {
+		t.Fatalf("EncodeTilesWithOptions failed: %v", err)
+	}
+	if tilesWithOptions != withOptions {
+		t.Errorf("Expected EncodeTilesWithOptions to produce %q, got %q", withOptions, tilesWithOptions)
+	}
+	decoded, decodedOpts, err := DecodeTilesWithOptions(withOptions)
+	if err != nil {
+		t.Fatalf("DecodeTilesWithOptions failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, cells) || !reflect.DeepEqual(decodedOpts, opts) {
+		t.Errorf("Expected DecodeTilesWithOptions to return the encoded cells and options %+v, got %+v", opts, decodedOpts)
+	}
+}
@@ -0,0 +1,486 @@
+package solver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"zenmojo/scoring"
+	"zenmojo/tiles"
+)
+
+// maxPackingSteps limits the search for a target layout, so that the solver
+// stays fast even for boards whose optimal shapes can't be packed.
+const maxPackingSteps = 100000
+
+// CostFunc returns the cost of exchanging the tiles at (x1, y1) and (x2, y2).
+// board.SwapMode.PathCost is the cost function for the game's swap modes.
+type CostFunc func(x1, y1, x2, y2 int) int
+
+// Move is a single exchange of the tiles in two cells.
+type Move struct {
+	X1, Y1 int
+	X2, Y2 int
+}
+
+// piece is a group of a single color in the target layout, together with the
+// orientations in which it may be placed.
+type piece struct {
+	cell     tiles.Cell
+	variants []scoring.Shape
+}
+
+// Solve returns a sequence of swaps that turns the grid into a target layout.
+// If the best shapes of all colors under the given rules can be packed onto
+// the board, the target layout reaches the maximum possible score; otherwise
+// it is the better of the largest partial packing that was found and every
+// color gathered into a single contiguous group, see targetLayout.
+//
+// The solution is found greedily and is not guaranteed to be the cheapest one,
+// but it is a good reference for how many moves a board needs.
+func Solve(grid tiles.Grid, rules scoring.ScoringRule, cost CostFunc) []Move {
+	if len(grid.Cells) == 0 || scoring.Score(grid, rules) == rules.MaxPossibleScore(grid) {
+		return nil
+	}
+
+	target := targetLayout(grid, rules)
+
+	// The board is symmetric, so every rotation and reflection of the target
+	// layout is just as good, unless the rules require shapes in a fixed orientation.
+	// Pick the one that is cheapest to reach.
+	shapeRules, _ := rules.(scoring.ShapeRuleSet)
+	variants := []tiles.Grid{target}
+	if shapeRules.AllowTransforms || len(shapeRules.Shapes) == 0 {
+		variants = symmetries(target)
+	}
+
+	var best []Move
+	bestCost := -1
+	for _, variant := range variants {
+		assignColors(variant, grid, shapeRules.Shapes)
+		moves := greedySwaps(grid, variant, cost)
+		if total := totalCost(moves, cost); bestCost == -1 || total < bestCost {
+			best, bestCost = moves, total
+		}
+	}
+	return best
+}
+
+// TargetScore returns the score of the target layout that Solve works towards.
+// It is the maximum possible score if the best shapes of all colors can be
+// packed onto the board. Unlike the maximum possible score, it can always be
+// reached, because the target layout has the same tiles as the grid.
+func TargetScore(grid tiles.Grid, rules scoring.ScoringRule) int {
+	if len(grid.Cells) == 0 {
+		return 0
+	}
+	return scoring.Score(targetLayout(grid, rules), rules)
+}
+
+// targetLayout returns the layout Solve works towards: a packing of the best
+// shapes of all colors if one is found. Otherwise, it is the largest partial
+// packing the search got to, with the remaining tiles in the free cells, or
+// every color gathered along a snake path if that scores more. The partial
+// packing keeps the target well above the snake layout under rules that only
+// score complete shapes.
+func targetLayout(grid tiles.Grid, rules scoring.ScoringRule) tiles.Grid {
+	target, ok := packTarget(grid, rules)
+	if ok {
+		return target
+	}
+	if snake := snakeTarget(grid); scoring.Score(snake, rules) > scoring.Score(target, rules) {
+		return snake
+	}
+	return target
+}
+
+// Par returns the total cost of the solution found by Solve.
+func Par(grid tiles.Grid, rules scoring.ScoringRule, cost CostFunc) int {
+	return totalCost(Solve(grid, rules, cost), cost)
+}
+
+func totalCost(moves []Move, cost CostFunc) int {
+	total := 0
+	for _, m := range moves {
+		total += cost(m.X1, m.Y1, m.X2, m.Y2)
+	}
+	return total
+}
+
+// targetPieces returns the piece every color should form under the given rules.
+// Colors with a required shape use that shape, all others their optimal rectangle.
+// Empty cells are kept as single-cell pieces.
+func targetPieces(grid tiles.Grid, rules scoring.ScoringRule) []piece {
+	shapeRules, _ := rules.(scoring.ShapeRuleSet)
+
+	var pieces []piece
+	for _, cell := range grid.Cells {
+		if cell == tiles.Empty {
+			pieces = append(pieces, piece{cell: tiles.Empty, variants: []scoring.Shape{rectangle(1, 1)}})
+		}
+	}
+	counts := grid.Counts()
+	for _, c := range colorOrder(grid) {
+		if shape, ok := shapeRules.Shapes[c]; ok && shape.Size() == counts[c] {
+			variants := []scoring.Shape{shape}
+			if shapeRules.AllowTransforms {
+				variants = shape.Variants()
+			}
+			pieces = append(pieces, piece{cell: c, variants: variants})
+			continue
+		}
+		w, h, _ := scoring.OptimalShape(counts[c])
+		pieces = append(pieces, piece{cell: c, variants: rectangle(w, h).Variants()})
+	}
+
+	// Placing large pieces first prunes the search much earlier.
+	sort.SliceStable(pieces, func(i, j int) bool {
+		return pieces[i].variants[0].Size() > pieces[j].variants[0].Size()
+	})
+	return pieces
+}
+
+// rectangle returns a solid shape of the given size.
+func rectangle(width, height int) scoring.Shape {
+	var rows []string
+	for r := 0; r < height; r++ {
+		rows = append(rows, strings.Repeat("X", width))
+	}
+	return scoring.NewShape("Rectangle", rows...)
+}
+
+// packTarget tries to tile the board with the target pieces of all colors.
+// ok is false if no tiling was found; target is then the partial tiling that
+// covered the most cells, with the remaining tiles filled into the free cells.
+func packTarget(grid tiles.Grid, rules scoring.ScoringRule) (target tiles.Grid, ok bool) {
+	rows, cols := grid.Height, grid.Width
+	pieces := targetPieces(grid, rules)
+
+	target = tiles.New(cols, rows)
+	filled := make([][]bool, rows)
+	for r := range filled {
+		filled[r] = make([]bool, cols)
+	}
+	used := make([]bool, len(pieces))
+	steps := 0
+
+	// The partial tiling that covered the most cells so far.
+	placed, bestPlaced := 0, -1
+	var best tiles.Grid
+	var bestFilled []bool
+
+	var place func(cell int) bool
+	place = func(cell int) bool {
+		// Find the first free cell in row-major order. Every tiling must cover it
+		// with the first cell of some piece.
+		for cell < rows*cols && filled[cell/cols][cell%cols] {
+			cell++
+		}
+		if cell == rows*cols {
+			return true
+		}
+		r, c := cell/cols, cell%cols
+
+		// Try the placements that agree most with the current board first, so that
+		// the target stays close to what the player has already built.
+		type candidate struct {
+			index   int
+			cells   []scoring.Coordinate
+			overlap int
+		}
+		var candidates []candidate
+		for i, p := range pieces {
+			if used[i] {
+				continue
+			}
+			for _, variant := range p.variants {
+				cells, ok := anchor(filled, variant, r, c)
+				if ok {
+					candidates = append(candidates, candidate{i, cells, overlap(grid, cells, p.cell)})
+				}
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].overlap > candidates[j].overlap
+		})
+
+		tried := make(map[string]bool)
+		for _, cand := range candidates {
+			// Pieces of the same shape are interchangeable for the rest of the search.
+			key := fmt.Sprint(cand.cells)
+			if tried[key] {
+				continue
+			}
+			tried[key] = true
+			steps++
+			if steps > maxPackingSteps {
+				return false
+			}
+			fill(filled, target, cand.cells, pieces[cand.index].cell, true)
+			used[cand.index] = true
+			placed += len(cand.cells)
+			if placed > bestPlaced {
+				bestPlaced, best = placed, target.Clone()
+				bestFilled = bestFilled[:0]
+				for _, row := range filled {
+					bestFilled = append(bestFilled, row...)
+				}
+			}
+			if place(cell + 1) {
+				return true
+			}
+			placed -= len(cand.cells)
+			used[cand.index] = false
+			fill(filled, target, cand.cells, tiles.Empty, false)
+		}
+		return false
+	}
+
+	if !place(0) {
+		if best.Cells == nil {
+			return snakeTarget(grid), false // Not even one piece fits
+		}
+		return completeTarget(grid, best, bestFilled), false
+	}
+	return target, true
+}
+
+// completeTarget fills the cells of a partial target layout that are not
+// filled with the tiles of the grid that the layout lacks, in row-major order.
+func completeTarget(grid, partial tiles.Grid, filled []bool) tiles.Grid {
+	missing := grid.Counts()
+	missing[tiles.Empty] = grid.Count(tiles.Empty)
+	for i, cell := range partial.Cells {
+		if filled[i] {
+			missing[cell]--
+		}
+	}
+	var remaining []tiles.Cell
+	for cell, n := range missing {
+		for ; n > 0; n-- {
+			remaining = append(remaining, tiles.Cell(cell))
+		}
+	}
+	for i := range partial.Cells {
+		if !filled[i] {
+			partial.Cells[i], remaining = remaining[0], remaining[1:]
+		}
+	}
+	return partial
+}
+
+// anchor places the shape so that its first cell covers (r, c) and returns the
+// covered cells. ok is false if the shape leaves the board or overlaps filled cells.
+func anchor(filled [][]bool, shape scoring.Shape, r, c int) (cells []scoring.Coordinate, ok bool) {
+	offsetR, offsetC := r-shape.Cells[0].R, c-shape.Cells[0].C
+	cells = make([]scoring.Coordinate, len(shape.Cells))
+	for i, sc := range shape.Cells {
+		cr, cc := sc.R+offsetR, sc.C+offsetC
+		if cr < 0 || cr >= len(filled) || cc < 0 || cc >= len(filled[0]) || filled[cr][cc] {
+			return nil, false
+		}
+		cells[i] = scoring.Coordinate{R: cr, C: cc}
+	}
+	return cells, true
+}
+
+// overlap counts the cells that already have the tile of the piece.
+func overlap(grid tiles.Grid, cells []scoring.Coordinate, pieceCell tiles.Cell) int {
+	count := 0
+	for _, cell := range cells {
+		if grid.At(cell.R, cell.C) == pieceCell {
+			count++
+		}
+	}
+	return count
+}
+
+func fill(filled [][]bool, target tiles.Grid, cells []scoring.Coordinate, pieceCell tiles.Cell, value bool) {
+	for _, cell := range cells {
+		filled[cell.R][cell.C] = value
+		target.Set(cell.R, cell.C, pieceCell)
+	}
+}
+
+// snakeTarget lays out the colors one after another along a snake path through
+// the rows. This keeps every color contiguous, even if it can't form a rectangle.
+func snakeTarget(grid tiles.Grid) tiles.Grid {
+	rows, cols := grid.Height, grid.Width
+	counts := grid.Counts()
+
+	var sequence []tiles.Cell
+	for _, c := range colorOrder(grid) {
+		for i := 0; i < counts[c]; i++ {
+			sequence = append(sequence, c)
+		}
+	}
+
+	target := tiles.New(cols, rows)
+	for i := 0; i < rows*cols; i++ {
+		r, c := i/cols, i%cols
+		if r%2 == 1 {
+			c = cols - 1 - c
+		}
+		if i < len(sequence) {
+			target.Set(r, c, sequence[i])
+		}
+	}
+	return target
+}
+
+// colorOrder returns the distinct colors of the grid in order of first appearance,
+// which keeps the solver deterministic.
+func colorOrder(grid tiles.Grid) []tiles.Cell {
+	var order []tiles.Cell
+	var seen [tiles.MaxColors + 1]bool
+	for _, cell := range grid.Cells {
+		if cell != tiles.Empty && !seen[cell] {
+			seen[cell] = true
+			order = append(order, cell)
+		}
+	}
+	return order
+}
+
+// symmetries returns the target layout in all of its rotations and reflections
+// that fit the board's dimensions.
+func symmetries(target tiles.Grid) []tiles.Grid {
+	rows, cols := target.Height, target.Width
+	transforms := []func(r, c int) (int, int){
+		func(r, c int) (int, int) { return r, c },
+		func(r, c int) (int, int) { return r, cols - 1 - c },
+		func(r, c int) (int, int) { return rows - 1 - r, c },
+		func(r, c int) (int, int) { return rows - 1 - r, cols - 1 - c },
+	}
+	if rows == cols {
+		transforms = append(transforms,
+			func(r, c int) (int, int) { return c, r },
+			func(r, c int) (int, int) { return c, cols - 1 - r },
+			func(r, c int) (int, int) { return rows - 1 - c, r },
+			func(r, c int) (int, int) { return rows - 1 - c, cols - 1 - r },
+		)
+	}
+
+	variants := make([]tiles.Grid, len(transforms))
+	for i, transform := range transforms {
+		variant := tiles.New(cols, rows)
+		for r := 0; r < rows; r++ {
+			for c := 0; c < cols; c++ {
+				tr, tc := transform(r, c)
+				variant.Set(tr, tc, target.At(r, c))
+			}
+		}
+		variants[i] = variant
+	}
+	return variants
+}
+
+// assignColors redistributes colors among the groups of the target layout that
+// have the same number of tiles. Each group is given the color that already has
+// the most tiles in its area, so fewer swaps are needed. Colors with a required
+// shape keep their group.
+func assignColors(target, grid tiles.Grid, required map[tiles.Cell]scoring.Shape) {
+	// Collect the cells of each target group.
+	regions := make(map[tiles.Cell][]scoring.Coordinate)
+	for r := 0; r < target.Height; r++ {
+		for c := 0; c < target.Width; c++ {
+			if cell := target.At(r, c); cell != tiles.Empty {
+				regions[cell] = append(regions[cell], scoring.Coordinate{R: r, C: c})
+			}
+		}
+	}
+
+	bySize := make(map[int][]tiles.Cell)
+	for _, c := range colorOrder(target) {
+		if _, ok := required[c]; ok {
+			continue
+		}
+		bySize[len(regions[c])] = append(bySize[len(regions[c])], c)
+	}
+
+	for _, colors := range bySize {
+		if len(colors) < 2 {
+			continue
+		}
+		type candidate struct {
+			color   tiles.Cell
+			region  tiles.Cell
+			overlap int
+		}
+		var candidates []candidate
+		for _, c := range colors {
+			for _, region := range colors {
+				overlap := 0
+				for _, cell := range regions[region] {
+					if grid.At(cell.R, cell.C) == c {
+						overlap++
+					}
+				}
+				candidates = append(candidates, candidate{color: c, region: region, overlap: overlap})
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].overlap > candidates[j].overlap
+		})
+
+		assignedColors := make(map[tiles.Cell]bool)
+		assignedRegions := make(map[tiles.Cell]bool)
+		for _, cand := range candidates {
+			if assignedColors[cand.color] || assignedRegions[cand.region] {
+				continue
+			}
+			assignedColors[cand.color] = true
+			assignedRegions[cand.region] = true
+			for _, cell := range regions[cand.region] {
+				target.Set(cell.R, cell.C, cand.color)
+			}
+		}
+	}
+}
+
+// greedySwaps returns swaps that turn the grid into the target. Swaps that put
+// two tiles in place at once are preferred; among equally useful swaps the
+// cheapest one is chosen.
+func greedySwaps(grid, target tiles.Grid, cost CostFunc) []Move {
+	rows, cols := grid.Height, grid.Width
+	current := grid.Clone()
+
+	var moves []Move
+	for {
+		var mismatched []scoring.Coordinate
+		for r := 0; r < rows; r++ {
+			for c := 0; c < cols; c++ {
+				if current.At(r, c) != target.At(r, c) {
+					mismatched = append(mismatched, scoring.Coordinate{R: r, C: c})
+				}
+			}
+		}
+		if len(mismatched) == 0 {
+			return moves
+		}
+
+		// Look for the cheapest swap that fixes both cells, then for the cheapest
+		// swap that fixes at least one. The latter always exists: a misplaced
+		// tile's color is missing somewhere else among the mismatched cells.
+		var best Move
+		bestCost := -1
+		for pass := 0; pass < 2 && bestCost == -1; pass++ {
+			for _, a := range mismatched {
+				for _, b := range mismatched {
+					if a == b || current.At(b.R, b.C) != target.At(a.R, a.C) {
+						continue
+					}
+					if pass == 0 && current.At(a.R, a.C) != target.At(b.R, b.C) {
+						continue
+					}
+					if swapCost := cost(a.C, a.R, b.C, b.R); bestCost == -1 || swapCost < bestCost {
+						best = Move{X1: a.C, Y1: a.R, X2: b.C, Y2: b.R}
+						bestCost = swapCost
+					}
+				}
+			}
+		}
+
+		current.Swap(best.Y1, best.X1, best.Y2, best.X2)
+		moves = append(moves, best)
+	}
+}
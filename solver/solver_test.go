@@ -0,0 +1,219 @@
+package solver
+
+import (
+	"math/rand"
+	"testing"
+	"zenmojo/scoring"
+	"zenmojo/tiles"
+)
+
+// freeCost is the cost function for boards where any two tiles can be swapped.
+func freeCost(x1, y1, x2, y2 int) int {
+	return 1
+}
+
+// distanceCost is the cost function for distance-weighted swaps.
+func distanceCost(x1, y1, x2, y2 int) int {
+	dx, dy := x1-x2, y1-y2
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	return dx + dy
+}
+
+// solvedGrid returns a 10x10 grid made of rectangles and lines that reaches the maximum score.
+func solvedGrid() tiles.Grid {
+	layout := []string{
+		"AAAABBBCCC",
+		"AAAABBBCCC",
+		"DDDDBBBCCC",
+		"DDDDEEEEEF",
+		"GGHHEEEEEF",
+		"GGHHIIIIIF",
+		"GGHHIIIIIF",
+		"JJJKKKLLLF",
+		"JJJKKKLLLF",
+		"JJJKKKLLLF",
+	}
+	return gridOf(layout)
+}
+
+// gridOf builds a grid from rows of letters, with every letter a color and
+// '.' an empty cell.
+func gridOf(layout []string) tiles.Grid {
+	grid := tiles.New(len(layout[0]), len(layout))
+	for r, row := range layout {
+		for c, ch := range row {
+			if ch != '.' {
+				grid.Set(r, c, tiles.CellOf(int(ch-'A')))
+			}
+		}
+	}
+	return grid
+}
+
+// unpackableGrid returns a sliding board whose best shapes the solver doesn't
+// manage to pack within maxPackingSteps.
+func unpackableGrid() tiles.Grid {
+	return gridOf([]string{
+		"LFAFMUMODP",
+		"THSECHJETS",
+		"NFKNNNRSNM",
+		"PAJKMTSIAL",
+		"TNELUMKONN",
+		"IDTKFEEFSD",
+		"FHJLAPTTRR",
+		"HHLIUEUASF",
+		"ORFHJRESCA",
+		"MSPNKKMHM.",
+	})
+}
+
+func applyMoves(grid tiles.Grid, moves []Move) {
+	for _, m := range moves {
+		grid.Swap(m.Y1, m.X1, m.Y2, m.X2)
+	}
+}
+
+func TestParOfSolvedBoardIsZero(t *testing.T) {
+	if par := Par(solvedGrid(), scoring.StandardRuleSet{}, freeCost); par != 0 {
+		t.Errorf("Expected par 0 for a solved board, got %d", par)
+	}
+}
+
+func TestSolveReachesMaxScore(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for trial := 0; trial < 20; trial++ {
+		grid := solvedGrid()
+		rows, cols := grid.Height, grid.Width
+		rng.Shuffle(rows*cols, func(i, j int) {
+			grid.Cells[i], grid.Cells[j] = grid.Cells[j], grid.Cells[i]
+		})
+
+		moves := Solve(grid, scoring.StandardRuleSet{}, freeCost)
+		applyMoves(grid, moves)
+
+		score := scoring.Score(grid, scoring.StandardRuleSet{})
+		maxScore := scoring.MaxScore(grid)
+		if score != maxScore {
+			t.Fatalf("Trial %d: expected the solution to reach %d points, got %d", trial, maxScore, score)
+		}
+		// Every swap puts at least one tile in place.
+		if len(moves) > rows*cols {
+			t.Errorf("Trial %d: expected at most %d moves, got %d", trial, rows*cols, len(moves))
+		}
+	}
+}
+
+func TestSolveWithEmptyCell(t *testing.T) {
+	grid := solvedGrid()
+	grid.Set(9, 9, tiles.Empty)
+	grid.Swap(0, 0, 5, 5)
+	grid.Swap(9, 9, 3, 3)
+
+	applyMoves(grid, Solve(grid, scoring.StandardRuleSet{}, freeCost))
+
+	score := scoring.Score(grid, scoring.StandardRuleSet{})
+	maxScore := scoring.MaxScore(grid)
+	if score != maxScore {
+		t.Errorf("Expected the solution to reach %d points, got %d", maxScore, score)
+	}
+}
+
+func TestTargetScoreIsReached(t *testing.T) {
+	// The square of A and the row of B don't fit next to each other on a 2x5
+	// board, so the maximum score is out of reach.
+	grid := gridOf([]string{
+		"AABBC",
+		"BAABB",
+	})
+	rules := scoring.StandardRuleSet{}
+	target := TargetScore(grid, rules)
+	if maxScore := rules.MaxPossibleScore(grid); target >= maxScore {
+		t.Errorf("Expected the target score to be below the unreachable maximum %d, got %d", maxScore, target)
+	}
+
+	applyMoves(grid, Solve(grid, rules, freeCost))
+	if score := scoring.Score(grid, rules); score < target {
+		t.Errorf("Expected the solution to reach the target score %d, got %d", target, score)
+	}
+}
+
+func TestTargetScoreOfPackableBoardIsMaxScore(t *testing.T) {
+	grid := solvedGrid()
+	grid.Swap(0, 0, 9, 9)
+	if target, maxScore := TargetScore(grid, scoring.StandardRuleSet{}), scoring.MaxScore(grid); target != maxScore {
+		t.Errorf("Expected the target score %d of a packable board to be the maximum, got %d", maxScore, target)
+	}
+}
+
+func TestTargetScoreOfUnpackableBoard(t *testing.T) {
+	grid := unpackableGrid()
+	rules := scoring.StandardRuleSet{}
+	if _, ok := packTarget(grid, rules); ok {
+		t.Fatal("Expected the packing to run out of steps")
+	}
+
+	// The largest partial packing scores far more than gathering the colors
+	// along a snake path, which only forms a few rectangles.
+	target := TargetScore(grid, rules)
+	if snake, maxScore := scoring.Score(snakeTarget(grid), rules), rules.MaxPossibleScore(grid); target <= snake || target < maxScore*3/4 {
+		t.Errorf("Expected a target score above %d and near the maximum %d, got %d", snake, maxScore, target)
+	}
+
+	applyMoves(grid, Solve(grid, rules, freeCost))
+	if score := scoring.Score(grid, rules); score < target {
+		t.Errorf("Expected the solution to reach the target score %d, got %d", target, score)
+	}
+}
+
+func TestParUsesCostFunction(t *testing.T) {
+	grid := solvedGrid()
+	// Swap two distant tiles of different colors.
+	grid.Swap(0, 0, 9, 9)
+
+	if par := Par(grid, scoring.StandardRuleSet{}, freeCost); par != 1 {
+		t.Errorf("Expected par 1 with free swaps, got %d", par)
+	}
+	if par := Par(grid, scoring.StandardRuleSet{}, distanceCost); par < 2 {
+		t.Errorf("Expected distance-weighted par to be larger than 1, got %d", par)
+	}
+}
+
+func TestSolveReachesRequiredShapes(t *testing.T) {
+	a, b, c, d := tiles.CellOf(0), tiles.CellOf(1), tiles.CellOf(2), tiles.CellOf(3)
+	rules := scoring.ShapeRuleSet{
+		Shapes: map[tiles.Cell]scoring.Shape{
+			a: scoring.NewShape("L", "XX", "X.", "X."),
+			b: scoring.NewShape("L", "XX", ".X", ".X"),
+			c: scoring.NewShape("Square", "XX", "XX"),
+			d: scoring.NewShape("Line", "XXXX"),
+		},
+		AllowTransforms: true,
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 10; trial++ {
+		grid := gridOf([]string{
+			"AABB",
+			"ACCB",
+			"ACCB",
+			"DDDD",
+		})
+		rng.Shuffle(16, func(i, j int) {
+			grid.Cells[i], grid.Cells[j] = grid.Cells[j], grid.Cells[i]
+		})
+
+		applyMoves(grid, Solve(grid, rules, freeCost))
+
+		score := scoring.Score(grid, rules)
+		maxScore := rules.MaxPossibleScore(grid)
+		if score != maxScore {
+			t.Fatalf("Trial %d: expected the solution to reach %d points, got %d", trial, maxScore, score)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Result describes a single finished game.
+type Result struct {
+	Mode     string // Name of the game mode, e.g. "Challenge"
+	Score    int
+	MaxScore int
+	Moves    int
+	Ticks    int // Game ticks the game lasted
+}
+
+// Percent returns the score as a percentage of the maximum score.
+func (r Result) Percent() int {
+	if r.MaxScore <= 0 {
+		return 100
+	}
+	return r.Score * 100 / r.MaxScore
+}
+
+// ModeStats aggregates the results of all games played in one game mode.
+type ModeStats struct {
+	Played      int `json:"played"`
+	TotalScore  int `json:"totalScore"`
+	BestScore   int `json:"bestScore"`
+	BestPercent int `json:"bestPercent"`
+}
+
+// Stats holds the statistics of all game modes.
+type Stats struct {
+	Modes map[string]*ModeStats `json:"modes"`
+}
+
+// New creates empty statistics.
+func New() *Stats {
+	return &Stats{Modes: make(map[string]*ModeStats)}
+}
+
+// Record adds a finished game to the statistics.
+// It returns true if the game set a new best percentage for its mode.
+func (s *Stats) Record(r Result) (isNewBest bool) {
+	m, ok := s.Modes[r.Mode]
+	if !ok {
+		m = &ModeStats{}
+		s.Modes[r.Mode] = m
+	}
+
+	m.Played++
+	m.TotalScore += r.Score
+	if r.Score > m.BestScore {
+		m.BestScore = r.Score
+	}
+	if m.Played == 1 || r.Percent() > m.BestPercent {
+		m.BestPercent = r.Percent()
+		isNewBest = true
+	}
+	return isNewBest
+}
+
+// Mode returns the statistics of a game mode. Modes that were never played have zero values.
+func (s *Stats) Mode(mode string) ModeStats {
+	if m, ok := s.Modes[mode]; ok {
+		return *m
+	}
+	return ModeStats{}
+}
+
+// Session accumulates the boards completed in one endless session. The
+// statistics count the whole session as one game, see Result.
+type Session struct {
+	Boards   int // Number of completed boards
+	Score    int // Sum of the scores of all completed boards
+	MaxScore int // Sum of the maximum scores of all completed boards
+	Moves    int // Moves spent on all completed boards
+	Ticks    int // Game ticks spent on all completed boards
+}
+
+// AddBoard adds a completed board to the session.
+func (s *Session) AddBoard(r Result) {
+	s.Boards++
+	s.Score += r.Score
+	s.MaxScore += r.MaxScore
+	s.Moves += r.Moves
+	s.Ticks += r.Ticks
+}
+
+// Result returns the session as a single game of the given mode, for recording
+// it in the statistics when the session ends.
+func (s Session) Result(mode string) Result {
+	return Result{Mode: mode, Score: s.Score, MaxScore: s.MaxScore, Moves: s.Moves, Ticks: s.Ticks}
+}
+
+// DefaultPath returns the location of the statistics file in the user's config directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "Zengo", "stats.json"), nil
+}
+
+// Load reads statistics from a file. A missing file yields empty statistics.
+func Load(path string) (*Stats, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := New()
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Modes == nil {
+		s.Modes = make(map[string]*ModeStats)
+	}
+	return s, nil
+}
+
+// Save writes the statistics to a file, creating its directory if needed.
+func (s *Stats) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
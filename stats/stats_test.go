@@ -0,0 +1,78 @@
+package stats
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	s := New()
+
+	if !s.Record(Result{Mode: "Time Attack", Score: 50, MaxScore: 200}) {
+		t.Error("Expected the first game of a mode to be a new best")
+	}
+	if s.Record(Result{Mode: "Time Attack", Score: 40, MaxScore: 200}) {
+		t.Error("Expected a lower percentage not to be a new best")
+	}
+	if !s.Record(Result{Mode: "Time Attack", Score: 90, MaxScore: 100}) {
+		t.Error("Expected a higher percentage to be a new best")
+	}
+
+	m := s.Mode("Time Attack")
+	if m.Played != 3 {
+		t.Errorf("Expected 3 games played, got %d", m.Played)
+	}
+	if m.TotalScore != 180 {
+		t.Errorf("Expected a total score of 180, got %d", m.TotalScore)
+	}
+	if m.BestScore != 90 {
+		t.Errorf("Expected a best score of 90, got %d", m.BestScore)
+	}
+	if m.BestPercent != 90 {
+		t.Errorf("Expected a best percentage of 90, got %d", m.BestPercent)
+	}
+
+	if other := s.Mode("Challenge"); other.Played != 0 {
+		t.Errorf("Expected no games for an unplayed mode, got %d", other.Played)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "stats.json")
+
+	// A missing file is not an error.
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading a missing file: %v", err)
+	}
+	s.Record(Result{Mode: "Challenge", Score: 75, MaxScore: 100})
+	if err := s.Save(path); err != nil {
+		t.Fatalf("Unexpected error saving: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading: %v", err)
+	}
+	if loaded.Mode("Challenge") != s.Mode("Challenge") {
+		t.Errorf("Expected %+v after loading, got %+v", s.Mode("Challenge"), loaded.Mode("Challenge"))
+	}
+}
+
+func TestSessionAddBoard(t *testing.T) {
+	var s Session
+	s.AddBoard(Result{Mode: "Zen", Score: 120, MaxScore: 120, Moves: 40, Ticks: 600})
+	s.AddBoard(Result{Mode: "Zen", Score: 95, MaxScore: 95, Moves: 35, Ticks: 500})
+
+	expected := Session{Boards: 2, Score: 215, MaxScore: 215, Moves: 75, Ticks: 1100}
+	if s != expected {
+		t.Errorf("Expected session %+v, got %+v", expected, s)
+	}
+
+	// The whole session counts as one game.
+	stats := New()
+	stats.Record(s.Result("Zen"))
+	if m := stats.Mode("Zen"); m.Played != 1 || m.TotalScore != 215 || m.BestPercent != 100 {
+		t.Errorf("Expected the session to be recorded as one game of 215 points, got %+v", m)
+	}
+}
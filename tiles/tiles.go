@@ -0,0 +1,158 @@
+// Package tiles provides the compact board model: a flat slice of palette
+// indices with a width and height. Tiles are compared by their index, and are
+// only turned into colors for drawing or for APIs that work with colors.
+package tiles
+
+import "image/color"
+
+// MaxColors is the number of distinct colors a grid can hold.
+const MaxColors = 255
+
+// Cell is the palette index of a tile plus one, so that the zero value is an empty cell.
+type Cell uint8
+
+// Empty is a cell without a tile, e.g. the gap in the sliding-puzzle variant.
+const Empty Cell = 0
+
+// CellOf returns the cell of the tile with the given palette index.
+func CellOf(paletteIndex int) Cell {
+	return Cell(paletteIndex + 1)
+}
+
+// PaletteIndex returns the palette index of the cell's tile, or -1 for an empty cell.
+func (c Cell) PaletteIndex() int {
+	return int(c) - 1
+}
+
+// Color returns the color of the cell's tile in the palette, or nil for an empty cell.
+func (c Cell) Color(palette []color.Color) color.Color {
+	if c == Empty {
+		return nil
+	}
+	return palette[c.PaletteIndex()]
+}
+
+// Grid is a board of cells, stored row by row.
+type Grid struct {
+	Width, Height int
+	Cells         []Cell
+}
+
+// New returns an empty grid of the given size.
+func New(width, height int) Grid {
+	return Grid{Width: width, Height: height, Cells: make([]Cell, width*height)}
+}
+
+// At returns the cell in row r and column c.
+func (g Grid) At(r, c int) Cell {
+	return g.Cells[r*g.Width+c]
+}
+
+// Set changes the cell in row r and column c.
+func (g Grid) Set(r, c int, cell Cell) {
+	g.Cells[r*g.Width+c] = cell
+}
+
+// Swap exchanges the cells at (r1, c1) and (r2, c2).
+func (g Grid) Swap(r1, c1, r2, c2 int) {
+	i, j := r1*g.Width+c1, r2*g.Width+c2
+	g.Cells[i], g.Cells[j] = g.Cells[j], g.Cells[i]
+}
+
+// Clone returns a copy of the grid that shares no cells with it.
+func (g Grid) Clone() Grid {
+	return Grid{Width: g.Width, Height: g.Height, Cells: append([]Cell(nil), g.Cells...)}
+}
+
+// Counts returns the number of tiles of each cell value, indexed by cell.
+// The count of empty cells is left at 0.
+func (g Grid) Counts() []int {
+	counts := make([]int, MaxColors+1)
+	for _, cell := range g.Cells {
+		if cell != Empty {
+			counts[cell]++
+		}
+	}
+	return counts
+}
+
+// Count returns the number of tiles of the given cell value.
+func (g Grid) Count(cell Cell) int {
+	count := 0
+	for _, c := range g.Cells {
+		if c == cell {
+			count++
+		}
+	}
+	return count
+}
+
+// Only returns a copy of the grid in which the tiles of all other cell values are removed.
+func (g Grid) Only(cell Cell) Grid {
+	only := New(g.Width, g.Height)
+	for i, c := range g.Cells {
+		if c == cell {
+			only.Cells[i] = cell
+		}
+	}
+	return only
+}
+
+// Colors converts the grid to rows of colors from the palette, with nil for empty cells.
+func (g Grid) Colors(palette []color.Color) [][]color.Color {
+	colors := make([][]color.Color, g.Height)
+	for r := range colors {
+		colors[r] = make([]color.Color, g.Width)
+		for c := range colors[r] {
+			colors[r][c] = g.At(r, c).Color(palette)
+		}
+	}
+	return colors
+}
+
+// FromColors converts rows of colors to a grid, with nil as an empty cell.
+// Colors are looked up in the palette by their RGBA values. Colors that are
+// missing from it are appended to a copy of the palette, which is returned
+// together with the grid. It panics if the grid has more than MaxColors colors.
+func FromColors(colors [][]color.Color, palette []color.Color) (Grid, []color.Color) {
+	index := make(map[[4]uint32]Cell, len(palette))
+	for i, p := range palette {
+		if key := rgbaKey(p); index[key] == Empty {
+			index[key] = CellOf(i)
+		}
+	}
+
+	extended := palette
+	g := Grid{Height: len(colors)}
+	if len(colors) > 0 {
+		g.Width = len(colors[0])
+	}
+	g.Cells = make([]Cell, g.Width*g.Height)
+	for r, row := range colors {
+		for c, item := range row {
+			if item == nil {
+				continue
+			}
+			key := rgbaKey(item)
+			cell, ok := index[key]
+			if !ok {
+				if len(extended) >= MaxColors {
+					panic("tiles: too many colors")
+				}
+				if len(extended) == len(palette) {
+					extended = append([]color.Color(nil), palette...)
+				}
+				extended = append(extended, item)
+				cell = CellOf(len(extended) - 1)
+				index[key] = cell
+			}
+			g.Set(r, c, cell)
+		}
+	}
+	return g, extended
+}
+
+func rgbaKey(c color.Color) [4]uint32 {
+	r, g, b, a := c.RGBA()
+	return [4]uint32{r, g, b, a}
+}
@@ -0,0 +1,92 @@
+package tiles
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestFromColorsRoundTrip(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	palette := []color.Color{red, blue}
+	colors := [][]color.Color{
+		{red, blue, nil},
+		{blue, red, red},
+	}
+
+	g, extended := FromColors(colors, palette)
+	if g.Width != 3 || g.Height != 2 {
+		t.Fatalf("Expected a 3x2 grid, got %dx%d", g.Width, g.Height)
+	}
+	if len(extended) != len(palette) {
+		t.Errorf("Expected the palette to stay unchanged, got %d colors", len(extended))
+	}
+	if g.At(0, 0) != CellOf(0) || g.At(0, 1) != CellOf(1) || g.At(0, 2) != Empty {
+		t.Errorf("Unexpected cells in the first row: %v", g.Cells[:3])
+	}
+
+	back := g.Colors(palette)
+	for r := range colors {
+		for c := range colors[r] {
+			if back[r][c] != colors[r][c] {
+				t.Errorf("Expected %v at (%d, %d), got %v", colors[r][c], r, c, back[r][c])
+			}
+		}
+	}
+}
+
+func TestFromColorsExtendsPalette(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	palette := []color.Color{red}
+	gray := color.Gray{Y: 128}
+	colors := [][]color.Color{
+		// Equal colors of another type share the palette entry.
+		{color.NRGBA{R: 255, A: 255}, gray},
+	}
+
+	g, extended := FromColors(colors, palette)
+	if len(extended) != 2 || extended[1] != gray {
+		t.Fatalf("Expected the palette to be extended by gray, got %v", extended)
+	}
+	if len(palette) != 1 {
+		t.Error("Expected the original palette to be unchanged")
+	}
+	if g.At(0, 0) != CellOf(0) || g.At(0, 1) != CellOf(1) {
+		t.Errorf("Unexpected cells %v", g.Cells)
+	}
+}
+
+func TestSwapAndClone(t *testing.T) {
+	g := New(2, 2)
+	g.Set(0, 0, CellOf(3))
+	clone := g.Clone()
+	g.Swap(0, 0, 1, 1)
+
+	if g.At(0, 0) != Empty || g.At(1, 1) != CellOf(3) {
+		t.Errorf("Expected the swap to move the tile, got %v", g.Cells)
+	}
+	if clone.At(0, 0) != CellOf(3) {
+		t.Error("Expected the clone to be unaffected by the swap")
+	}
+	if counts := g.Counts(); counts[CellOf(3)] != 1 || counts[Empty] != 0 {
+		t.Errorf("Unexpected counts %v", counts[:5])
+	}
+}
+
+func TestCountAndOnly(t *testing.T) {
+	g := New(3, 1)
+	g.Set(0, 0, CellOf(0))
+	g.Set(0, 1, CellOf(1))
+	g.Set(0, 2, CellOf(0))
+
+	if count := g.Count(CellOf(0)); count != 2 {
+		t.Errorf("Expected 2 tiles of the first color, got %d", count)
+	}
+	only := g.Only(CellOf(0))
+	if only.At(0, 0) != CellOf(0) || only.At(0, 1) != Empty || only.At(0, 2) != CellOf(0) {
+		t.Errorf("Expected only the tiles of the first color, got %v", only.Cells)
+	}
+	if g.At(0, 1) != CellOf(1) {
+		t.Error("Expected the original grid to be unchanged")
+	}
+}
@@ -0,0 +1,95 @@
+package view
+
+import (
+	"image/color"
+	"zenmojo/config"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// spriteState is the look of a tile on the board.
+type spriteState int
+
+const (
+	// spriteRegular is a square tile resting on the board, with its shadow.
+	spriteRegular spriteState = iota
+	// spriteHovered is a square tile lifted off the board, without a shadow.
+	// Tiles under the mouse cursor and moving tiles are drawn like this.
+	spriteHovered
+	// spriteSelected is the round selected tile with its shadow.
+	spriteSelected
+	// spriteDimmed is a regular tile that is dimmed while another color is highlighted.
+	spriteDimmed
+)
+
+// shadowOffset is how far the shadow of a tile reaches to the bottom right.
+const shadowOffset = 2
+
+// spriteKey identifies a sprite in the cache.
+type spriteKey struct {
+	color color.Color
+	state spriteState
+}
+
+// sprites caches the rendered tiles. Each combination of palette color and
+// state is rendered once and then reused in every frame. Ebiten packs such
+// small images into a shared texture atlas, so drawing them is cheap.
+var sprites = make(map[spriteKey]*ebiten.Image)
+
+// spritesSize is the tile size of the sprites in the cache.
+var spritesSize int
+
+// sprite returns the cached sprite of a tile of the given size, rendering it
+// on first use. The tile itself starts at the top left corner of the sprite.
+// When the tiles change their size with the layout, the sprites of the old
+// size are thrown away.
+func sprite(pieceColor color.Color, state spriteState, size int) *ebiten.Image {
+	if size != spritesSize {
+		for key, img := range sprites {
+			img.Deallocate()
+			delete(sprites, key)
+		}
+		spritesSize = size
+	}
+	key := spriteKey{color: pieceColor, state: state}
+	if img, ok := sprites[key]; ok {
+		return img
+	}
+	img := renderSprite(pieceColor, state, size)
+	sprites[key] = img
+	return img
+}
+
+// renderSprite draws a tile of the given size in the given state on a new image.
+// The image leaves room for the shadow, and a pixel for the anti-aliased edge
+// of the round selected tile.
+func renderSprite(pieceColor color.Color, state spriteState, square int) *ebiten.Image {
+	accentColor, ok := config.AccentColors[pieceColor]
+	if !ok {
+		accentColor = config.White // Default to white
+	}
+
+	img := ebiten.NewImage(square+shadowOffset+1, square+shadowOffset+1)
+	size := float32(square)
+
+	if state == spriteSelected {
+		cx, cy, r := size/2, size/2, size/2
+		vector.DrawFilledCircle(img, cx+shadowOffset, cy+shadowOffset, r, config.ShadowColor, true)
+		vector.DrawFilledCircle(img, cx, cy, r, pieceColor, true)
+		vector.DrawFilledCircle(img, cx, cy, float32(square/8), accentColor, true)
+		return img
+	}
+
+	if state != spriteHovered {
+		vector.DrawFilledRect(img, shadowOffset, shadowOffset, size, size, config.ShadowColor, false)
+	}
+	vector.DrawFilledRect(img, 0, 0, size, size, pieceColor, false)
+	accentSize := float32(square / 4)
+	vector.DrawFilledRect(img, size/8, size/8, accentSize, accentSize, accentColor, false)
+	if state == spriteDimmed {
+		// Cover the shadow as well, so the dimmed tile sinks into the background.
+		vector.DrawFilledRect(img, 0, 0, size+shadowOffset, size+shadowOffset, config.DimColor, false)
+	}
+	return img
+}
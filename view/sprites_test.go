@@ -0,0 +1,92 @@
+package view
+
+import (
+	"image/color"
+	"testing"
+	"zenmojo/board"
+	"zenmojo/config"
+	"zenmojo/layout"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+func TestSpriteCacheReusesImages(t *testing.T) {
+	selected := sprite(config.Red, spriteSelected, config.SquareSize)
+	if sprite(config.Red, spriteSelected, config.SquareSize) != selected {
+		t.Error("Expected the cached sprite to be reused")
+	}
+	if sprite(config.Red, spriteRegular, config.SquareSize) == selected {
+		t.Error("Expected different states to have different sprites")
+	}
+	if sprite(config.Blue, spriteSelected, config.SquareSize) == selected {
+		t.Error("Expected different colors to have different sprites")
+	}
+	larger := sprite(config.Red, spriteSelected, 2*config.SquareSize)
+	if larger.Bounds().Dx() <= selected.Bounds().Dx() {
+		t.Error("Expected a larger sprite for larger tiles")
+	}
+}
+
+// BenchmarkDrawBoard draws a full board from the sprite cache. After the first
+// frame no images are created anymore.
+func BenchmarkDrawBoard(b *testing.B) {
+	screen := ebiten.NewImage(config.ScreenWidth, config.ScreenHeight)
+	l := layout.New(config.ScreenWidth, config.ScreenHeight, false)
+	bd := board.New()
+	drawBoard(screen, l, bd, config.Red, -1, -1) // Fill the cache
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		drawBoard(screen, l, bd, config.Red, -1, -1)
+	}
+}
+
+// BenchmarkDrawBoardUncached draws a full board with drawPieceUncached, the
+// way the board was drawn before the sprite cache, for comparison with
+// BenchmarkDrawBoard.
+func BenchmarkDrawBoardUncached(b *testing.B) {
+	screen := ebiten.NewImage(config.ScreenWidth, config.ScreenHeight)
+	l := layout.New(config.ScreenWidth, config.ScreenHeight, false)
+	bd := board.New()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for x := 0; x < config.GridSize; x++ {
+			for y := 0; y < config.GridSize; y++ {
+				cellX, cellY := l.Cell(x, y)
+				drawPieceUncached(screen, tileColor(bd, x, y), cellX, cellY)
+			}
+		}
+	}
+}
+
+// drawPieceUncached is the drawing of a regular piece before the sprite
+// cache: a shadow, and a new image each for the piece and its accent.
+func drawPieceUncached(screen *ebiten.Image, pieceColor color.Color, x, y int) {
+	if pieceColor == nil {
+		return
+	}
+	shadowOffset := 2
+	vector.DrawFilledRect(screen, float32(x+shadowOffset), float32(y+shadowOffset), float32(config.SquareSize), float32(config.SquareSize), config.ShadowColor, false)
+
+	accentColor, ok := config.AccentColors[pieceColor]
+	if !ok {
+		accentColor = config.White // Default to white
+	}
+
+	square := ebiten.NewImage(config.SquareSize, config.SquareSize)
+	square.Fill(pieceColor)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(x), float64(y))
+	screen.DrawImage(square, op)
+
+	accentSize := config.SquareSize / 4
+	accentSquare := ebiten.NewImage(accentSize, accentSize)
+	accentSquare.Fill(accentColor)
+	accentOp := &ebiten.DrawImageOptions{}
+	accentOp.GeoM.Translate(float64(x)+float64(config.SquareSize)/8, float64(y)+float64(config.SquareSize)/8)
+	screen.DrawImage(accentSquare, accentOp)
+}
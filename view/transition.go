@@ -0,0 +1,92 @@
+package view
+
+import (
+	"image/color"
+	"zenmojo/board"
+	"zenmojo/config"
+	"zenmojo/layout"
+	"zenmojo/scene"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// staggerSpread is the fraction of a transition phase by which the last tile
+// lags behind the first one. It makes the tiles dissolve and appear in a wave.
+const staggerSpread = 0.4
+
+// drawTransition renders the transition in place of the board. In the first
+// half the tiles of the finished board dissolve, in the second half the tiles
+// of the new board fade in.
+//
+//go:noinline
+func drawTransition(screen *ebiten.Image, l layout.Layout, t *scene.Transition, b *board.Board) {
+	phase := t.Progress() * 2
+	dissolving := phase < 1
+	if !dissolving {
+		phase -= 1
+	}
+
+	for i := 0; i < config.GridSize; i++ {
+		for j := 0; j < config.GridSize; j++ {
+			var pieceColor color.Color
+			if dissolving {
+				pieceColor = t.From(i, j)
+			} else {
+				pieceColor = tileColor(b, i, j)
+			}
+			if pieceColor == nil {
+				continue
+			}
+
+			// Tiles further down and to the right start later.
+			delay := float64(i+j) / float64(2*(config.GridSize-1)) * staggerSpread
+			p := (phase - delay) / (1 - staggerSpread)
+			if p < 0 {
+				p = 0
+			} else if p > 1 {
+				p = 1
+			}
+
+			// Dissolving tiles shrink and fade out, new tiles grow and fade in.
+			visibility := p
+			if dissolving {
+				visibility = 1 - p
+			}
+			if visibility <= 0 {
+				continue
+			}
+
+			x, y := l.Cell(i, j)
+			drawFadingPiece(screen, pieceColor, float64(l.Square), float64(x), float64(y), visibility)
+		}
+	}
+}
+
+// drawFadingPiece draws a piece of the given size, scaled around its center and faded by the
+// given visibility between 0 (invisible) and 1 (regular piece).
+func drawFadingPiece(screen *ebiten.Image, pieceColor color.Color, square, x, y, visibility float64) {
+	accentColor, ok := config.AccentColors[pieceColor]
+	if !ok {
+		accentColor = config.White // Default to white
+	}
+
+	size := square * (0.5 + visibility/2)
+	offset := (square - size) / 2
+	vector.DrawFilledRect(screen, float32(x+offset), float32(y+offset), float32(size), float32(size), fade(pieceColor, visibility), false)
+
+	accentSize := size / 4
+	vector.DrawFilledRect(screen, float32(x+offset+size/8), float32(y+offset+size/8), float32(accentSize), float32(accentSize), fade(accentColor, visibility), false)
+}
+
+// fade returns the color with its opacity multiplied by alpha.
+func fade(c color.Color, alpha float64) color.Color {
+	r, g, b, a := c.RGBA()
+	// color.RGBA is alpha-premultiplied, so all channels are scaled.
+	return color.RGBA{
+		R: uint8(float64(r>>8) * alpha),
+		G: uint8(float64(g>>8) * alpha),
+		B: uint8(float64(b>>8) * alpha),
+		A: uint8(float64(a>>8) * alpha),
+	}
+}
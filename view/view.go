@@ -2,10 +2,16 @@ package view
 
 import (
 	"fmt"
+	"image"
 	"image/color"
+	"math"
 	"sort"
 	"zenmojo/board"
 	"zenmojo/config"
+	"zenmojo/layout"
+	"zenmojo/scene"
+	"zenmojo/scoring"
+	"zenmojo/tiles"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text"
@@ -13,50 +19,95 @@ import (
 	"golang.org/x/image/font"
 )
 
-// Draw renders the entire game screen.
+// Draw renders the entire game screen. While a transition between boards is
+// running, it is drawn in place of the board. In target-shape puzzles, shapes
+// holds the required shape of each color; otherwise it is nil. If highlight is
+// not nil, all tiles of other colors are dimmed. Outlines frame the groups
+// that already score. If preview is not nil, the swap it describes is shown
+// as a ghost together with the change of the score. If drag is not nil, the
+// dragged tile follows the mouse cursor. If cursor is not nil, the board cursor
+// of the keyboard is framed, and the preview refers to it.
 //
 //go:noinline
-func Draw(screen *ebiten.Image, b *board.Board, score, maxScore, moveCount int, scoreHistory []int, colorCounts map[color.Color]int, mouseX, mouseY int) {
-	drawBackground(screen)
-	drawBoard(screen, b, mouseX, mouseY)
-	drawUI(screen, score, maxScore, moveCount, scoreHistory)
-	drawStoneDistribution(screen, colorCounts)
-	// Note: DrawSharingUI is now called from Game.Draw to be on top of everything.
+func Draw(screen *ebiten.Image, l layout.Layout, b *board.Board, status scene.Status, scoreHistory []int, breakdown map[color.Color]scoring.ColorBreakdown, shapes map[tiles.Cell]scoring.Shape, highlight color.Color, outlines []scene.Outline, preview *scene.Preview, drag *scene.Drag, cursor *scene.Cursor, transition *scene.Transition, mouseX, mouseY int) {
+	drawBackground(screen, l)
+	if transition != nil {
+		drawTransition(screen, l, transition, b)
+	} else {
+		drawBoard(screen, l, b, highlight, mouseX, mouseY)
+		if !b.IsAnimating {
+			drawOutlines(screen, l, outlines)
+			drawPreview(screen, l, b, preview)
+			drawDrag(screen, l, b, drag, preview, mouseX, mouseY)
+			if preview != nil {
+				// Show the change of the score next to the mouse cursor, the dragged tile or the board cursor
+				deltaX, deltaY := mouseX+14, mouseY-4
+				if drag != nil {
+					deltaX, deltaY = mouseX-drag.GrabX+l.Square+8, mouseY-drag.GrabY+12
+				} else if cursor != nil {
+					cellX, cellY := l.Cell(cursor.I, cursor.J)
+					deltaX, deltaY = cellX+l.Square+8, cellY+12
+				}
+				drawScoreDelta(screen, preview.Delta, deltaX, deltaY)
+			}
+		}
+		drawCursor(screen, l, cursor)
+	}
+	drawUI(screen, l, status, scoreHistory)
+	drawStoneDistribution(screen, l, breakdown, shapesByColor(shapes, b.Palette()), highlight)
+	// Note: DrawSharingUI is called from DrawFrame to be on top of everything.
+}
+
+// DrawFrame renders the whole frame: the game screen, the results of a
+// finished game and the settings menu on top of it, and the share code on
+// top of everything.
+func DrawFrame(screen *ebiten.Image, f scene.Frame) {
+	Draw(screen, f.Layout, f.Board, f.Status, f.ScoreHistory, f.Breakdown, f.Shapes, f.Highlight, f.Outlines, f.Preview, f.Drag, f.Cursor, f.Transition, f.MouseX, f.MouseY)
+	if f.Results != nil {
+		DrawResults(screen, f.Layout, f.Results.Title, f.Results.Lines)
+	}
+	if f.Settings != nil {
+		DrawSettings(screen, f.Layout, f.Settings.Title, f.Settings.Options, f.Settings.Focus)
+	}
+	DrawSharingUI(screen, f.Layout, f.ShareCode, f.Feedback, f.IsCustomBoard)
+}
+
+// hatchingPattern is the tile of diagonal lines that covers the background.
+var hatchingPattern = newHatchingPattern()
+
+// newHatchingPattern draws the tile of the background hatching.
+func newHatchingPattern() *ebiten.Image {
+	const patternSize = 20
+	pattern := ebiten.NewImage(patternSize, patternSize)
+	for i := -patternSize; i < patternSize; i += 4 {
+		vector.StrokeLine(pattern, float32(i), 0, float32(i+patternSize), float32(patternSize), 1, config.HatchingColor, false)
+	}
+	return pattern
 }
 
 //go:noinline
-func drawBackground(screen *ebiten.Image) {
+func drawBackground(screen *ebiten.Image, l layout.Layout) {
 	screen.Fill(config.BackgroundColor)
 
 	// Draw hatching pattern
-	patternSize := config.HatchingPattern.Bounds().Dx()
-	for i := 0; i < config.ScreenWidth; i += patternSize {
-		for j := 0; j < config.ScreenHeight; j += patternSize {
+	patternSize := hatchingPattern.Bounds().Dx()
+	for i := 0; i < l.Width; i += patternSize {
+		for j := 0; j < l.Height; j += patternSize {
 			op := &ebiten.DrawImageOptions{}
 			op.GeoM.Translate(float64(i), float64(j))
-			screen.DrawImage(config.HatchingPattern, op)
+			screen.DrawImage(hatchingPattern, op)
 		}
 	}
 }
 
 //go:noinline
-func drawBoard(screen *ebiten.Image, b *board.Board, mouseX int, mouseY int) {
+func drawBoard(screen *ebiten.Image, l layout.Layout, b *board.Board, highlight color.Color, mouseX int, mouseY int) {
 	if b.IsAnimating {
 		// Animation logic
 		p1x, p1y, p2x, p2y := b.AnimatingPieces()
-		progress := b.AnimationProgress
-
-		// Calculate interpolated positions in terms of screen coordinates
-		p1StartX := float64(config.GridOriginX + p1x*(config.SquareSize+config.Gap))
-		p1StartY := float64(config.GridOriginY + p1y*(config.SquareSize+config.Gap))
-		p1EndX := float64(config.GridOriginX + p2x*(config.SquareSize+config.Gap))
-		p1EndY := float64(config.GridOriginY + p2y*(config.SquareSize+config.Gap))
-
-		p1CurrentX := p1StartX + (p1EndX-p1StartX)*progress
-		p1CurrentY := p1StartY + (p1EndY-p1StartY)*progress
-
-		p2CurrentX := p1EndX + (p1StartX-p1EndX)*progress
-		p2CurrentY := p1EndY + (p1StartY-p1EndY)*progress
+		startX, startY := l.Cell(p1x, p1y)
+		endX, endY := l.Cell(p2x, p2y)
+		path := swapPath(float64(startX), float64(startY), float64(endX), float64(endY), b.EasedProgress(), b.AnimationProgress)
 
 		// Draw all non-animating pieces
 		for i := 0; i < config.GridSize; i++ {
@@ -64,114 +115,238 @@ func drawBoard(screen *ebiten.Image, b *board.Board, mouseX int, mouseY int) {
 				if (i == p1x && j == p1y) || (i == p2x && j == p2y) {
 					continue // Skip animating pieces, they will be drawn on top
 				}
-				drawPiece(screen, b, i, j, -1, -1) // Pass -1 for mouse to avoid hover effects
+				drawPiece(screen, l, b, i, j, highlight, -1, -1) // Pass -1 for mouse to avoid hover effects
 			}
 		}
 
-		// Draw the two animating pieces at their interpolated positions
-		color1 := b.Grid()[p1y][p1x]
-		color2 := b.Grid()[p2y][p2x]
-		drawPieceAt(screen, color1, p1CurrentX, p1CurrentY, false)
-		drawPieceAt(screen, color2, p2CurrentX, p2CurrentY, false)
+		// Draw the two animating pieces lifted off the board, along their arcs
+		drawLiftedPiece(screen, tileColor(b, p1x, p1y), l.Square, path.x1, path.y1, path.lift)
+		drawLiftedPiece(screen, tileColor(b, p2x, p2y), l.Square, path.x2, path.y2, path.lift)
 
 	} else {
 		// Original drawing logic if not animating
 		for i := 0; i < config.GridSize; i++ {
 			for j := 0; j < config.GridSize; j++ {
-				drawPiece(screen, b, i, j, mouseX, mouseY)
+				drawPiece(screen, l, b, i, j, highlight, mouseX, mouseY)
 			}
 		}
 	}
 }
 
+// drawOutlines frames every scoring group, and adds a fading glow around
+// groups that just started scoring.
+//
+//go:noinline
+func drawOutlines(screen *ebiten.Image, l layout.Layout, outlines []scene.Outline) {
+	const (
+		margin     = 3 // Distance of the frame from the tiles
+		glowLayers = 3
+	)
+	for _, o := range outlines {
+		cellX, cellY := l.Cell(o.MinC, o.MinR)
+		x, y := float32(cellX-margin), float32(cellY-margin)
+		w := float32((o.MaxC-o.MinC+1)*(l.Square+l.Gap) - l.Gap + 2*margin)
+		h := float32((o.MaxR-o.MinR+1)*(l.Square+l.Gap) - l.Gap + 2*margin)
+
+		// Each glow layer is wider and fainter than the one inside it.
+		for layer := glowLayers; layer >= 1 && o.Glow > 0; layer-- {
+			spread := float32(2 * layer)
+			alpha := o.Glow * (1 - float64(layer-1)/glowLayers)
+			vector.StrokeRect(screen, x-spread, y-spread, w+2*spread, h+2*spread, 3, fade(config.GlowColor, alpha), true)
+		}
+		vector.StrokeRect(screen, x, y, w, h, 2, config.OutlineColor, true)
+	}
+}
+
+// drawPreview shows the two tiles of a previewed swap as ghosts at their new positions.
+//
+//go:noinline
+func drawPreview(screen *ebiten.Image, l layout.Layout, b *board.Board, preview *scene.Preview) {
+	if preview == nil {
+		return
+	}
+	const ghostVisibility = 0.7
+
+	color1 := tileColor(b, preview.X1, preview.Y1)
+	color2 := tileColor(b, preview.X2, preview.Y2)
+	for _, ghost := range []struct {
+		i, j  int
+		color color.Color
+	}{
+		{preview.X1, preview.Y1, color2},
+		{preview.X2, preview.Y2, color1},
+	} {
+		cellX, cellY := l.Cell(ghost.i, ghost.j)
+		x, y := float64(cellX), float64(cellY)
+		// Cover the current tile and its shadow before drawing the ghost.
+		coverCell(screen, l, ghost.i, ghost.j)
+		if ghost.color != nil {
+			drawFadingPiece(screen, ghost.color, float64(l.Square), x, y, ghostVisibility)
+		}
+	}
+}
+
+// drawScoreDelta shows by how much a swap would change the score, with its
+// baseline starting at (x, y).
+func drawScoreDelta(screen *ebiten.Image, delta int, x, y int) {
+	deltaColor := config.Black
+	if delta > 0 {
+		deltaColor = config.Green
+	} else if delta < 0 {
+		deltaColor = config.Red
+	}
+	deltaStr := fmt.Sprintf("%+d", delta)
+	if delta == 0 {
+		deltaStr = "±0"
+	}
+	text.Draw(screen, deltaStr, config.STextFace, x, y, deltaColor)
+}
+
+// coverCell hides the tile at grid position (i, j) and its shadow under the background.
+func coverCell(screen *ebiten.Image, l layout.Layout, i, j int) {
+	cellX, cellY := l.Cell(i, j)
+	x, y := float32(cellX), float32(cellY)
+	vector.DrawFilledRect(screen, x, y, float32(l.Square+shadowOffset), float32(l.Square+shadowOffset), config.BackgroundColor, false)
+}
+
+// drawDrag draws the dragged tile lifted off the board under the mouse cursor.
+// If it would be swapped when dropped, preview describes that swap and the
+// tile it would be swapped with is framed.
+//
+//go:noinline
+func drawDrag(screen *ebiten.Image, l layout.Layout, b *board.Board, drag *scene.Drag, preview *scene.Preview, mouseX, mouseY int) {
+	if drag == nil {
+		return
+	}
+	if preview != nil {
+		const margin = 3 // Distance of the frame from the tile
+		cellX, cellY := l.Cell(preview.X2, preview.Y2)
+		x, y := float32(cellX-margin), float32(cellY-margin)
+		size := float32(l.Square + 2*margin)
+		vector.StrokeRect(screen, x, y, size, size, 3, config.DropTargetColor, true)
+	} else {
+		// The tile has been picked up and left its cell empty
+		coverCell(screen, l, drag.I, drag.J)
+	}
+	x, y := float64(mouseX-drag.GrabX), float64(mouseY-drag.GrabY)
+	drawLiftedPiece(screen, tileColor(b, drag.I, drag.J), l.Square, x, y, 1)
+}
+
+// tileColor returns the color of the tile at grid position (i, j), or nil if the cell is empty.
+func tileColor(b *board.Board, i, j int) color.Color {
+	return b.Cells().At(j, i).Color(b.Palette())
+}
+
 // drawPiece draws a single piece from the board at its grid position (i, j).
+// If highlight is not nil and the piece has another color, it is dimmed,
+// unless it is selected or under the mouse cursor.
 //
 //go:noinline
-func drawPiece(screen *ebiten.Image, b *board.Board, i, j, mouseX, mouseY int) {
-	x := config.GridOriginX + i*(config.SquareSize+config.Gap)
-	y := config.GridOriginY + j*(config.SquareSize+config.Gap)
+func drawPiece(screen *ebiten.Image, l layout.Layout, b *board.Board, i, j int, highlight color.Color, mouseX, mouseY int) {
+	color := tileColor(b, i, j)
+	if color == nil {
+		return // Empty cells have neither a shadow nor a hover effect
+	}
+
+	x, y := l.Cell(i, j)
 	selectedX, selectedY := b.Selected()
 	isSelected := (i == selectedX && j == selectedY)
-	isHovered := mouseX >= x && mouseX < x+config.SquareSize && mouseY >= y && mouseY < y+config.SquareSize
+	isHovered := mouseX >= x && mouseX < x+l.Square && mouseY >= y && mouseY < y+l.Square
 
 	drawX, drawY := float64(x), float64(y)
-
-	// Apply hover effect if not selected
-	if isHovered && !isSelected {
+	state := spriteRegular
+	switch {
+	case isSelected:
+		state = spriteSelected
+	case isHovered:
+		// Hovered pieces are lifted off the board and lose their shadow
+		state = spriteHovered
 		drawX += 1
 		drawY += 1
+	case highlight != nil && color != highlight:
+		state = spriteDimmed
 	}
 
-	// Draw shadow unless it's selected or hovered
-	if !isSelected && !isHovered {
-		shadowOffset := 2
-		vector.DrawFilledRect(screen, float32(x+shadowOffset), float32(y+shadowOffset), float32(config.SquareSize), float32(config.SquareSize), config.ShadowColor, false)
-	}
-
-	color := b.Grid()[j][i]
-	drawPieceAt(screen, color, drawX, drawY, isSelected)
+	drawPieceAt(screen, color, state, l.Square, drawX, drawY)
 }
 
-// drawPieceAt dispatches drawing to specialized functions based on selection state.
+// drawPieceAt draws a piece of the given size in the given state from the sprite cache.
 //
 //go:noinline
-func drawPieceAt(screen *ebiten.Image, pieceColor color.Color, x, y float64, isSelected bool) {
+func drawPieceAt(screen *ebiten.Image, pieceColor color.Color, state spriteState, size int, x, y float64) {
 	if pieceColor == nil {
 		return // Don't draw empty cells
 	}
-	if isSelected {
-		drawSelectedPiece(screen, pieceColor, x, y)
-	} else {
-		drawRegularPiece(screen, pieceColor, x, y)
-	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(x, y)
+	screen.DrawImage(sprite(pieceColor, state, size), op)
 }
 
-// drawRegularPiece draws a standard square piece.
+// drawCursor frames the cell of the board cursor.
 //
 //go:noinline
-func drawRegularPiece(screen *ebiten.Image, pieceColor color.Color, x, y float64) {
-	accentColor, ok := config.AccentColors[pieceColor]
-	if !ok {
-		accentColor = config.White // Default to white
+func drawCursor(screen *ebiten.Image, l layout.Layout, cursor *scene.Cursor) {
+	if cursor == nil {
+		return
 	}
+	const margin = 4 // Distance of the frame from the tile, outside of the frames of drop targets
+	cellX, cellY := l.Cell(cursor.I, cursor.J)
+	x, y := float32(cellX-margin), float32(cellY-margin)
+	size := float32(l.Square + 2*margin)
+	vector.StrokeRect(screen, x, y, size, size, 2, config.CursorColor, true)
+}
 
-	square := ebiten.NewImage(config.SquareSize, config.SquareSize)
-	square.Fill(pieceColor)
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(x, y)
-	screen.DrawImage(square, op)
+// flight is where the two tiles of a swap are at a moment of the animation.
+type flight struct {
+	x1, y1 float64 // Position of the tile that started at the first cell
+	x2, y2 float64 // Position of the tile that started at the second cell
+	lift   float64 // How far both tiles are lifted off the board, from 0 to 1
+}
 
-	accentSize := config.SquareSize / 4
-	accentSquare := ebiten.NewImage(accentSize, accentSize)
-	accentSquare.Fill(accentColor)
-	accentOp := &ebiten.DrawImageOptions{}
-	accentOp.GeoM.Translate(x+float64(config.SquareSize)/8, y+float64(config.SquareSize)/8)
-	screen.DrawImage(accentSquare, accentOp)
+// swapPath returns the positions of the tiles swapped between (startX, startY)
+// and (endX, endY). eased is the eased progress that moves the tiles, progress
+// the linear one that raises and lowers them, so they land exactly in place
+// even if the easing overshoots. The tiles travel along opposite arcs, so
+// that they pass each other instead of overlapping midway.
+func swapPath(startX, startY, endX, endY, eased, progress float64) flight {
+	dx, dy := endX-startX, endY-startY
+	lift := math.Sin(math.Pi * math.Min(math.Max(progress, 0), 1))
+
+	// Both tiles are pushed off the straight line by the same offset, at a right
+	// angle to it. As they travel in opposite directions, they bend to opposite sides.
+	offsetX, offsetY := -dy*config.SwapArc*lift, dx*config.SwapArc*lift
+	return flight{
+		x1:   startX + dx*eased + offsetX,
+		y1:   startY + dy*eased + offsetY,
+		x2:   endX - dx*eased - offsetX,
+		y2:   endY - dy*eased - offsetY,
+		lift: lift,
+	}
 }
 
-// drawSelectedPiece draws a circular selected piece.
-//
-//go:noinline
-func drawSelectedPiece(screen *ebiten.Image, pieceColor color.Color, x, y float64) {
-	accentColor, ok := config.AccentColors[pieceColor]
-	if !ok {
-		accentColor = config.White // Default to white
+// drawLiftedPiece draws a moving tile of the given size at (x, y). The further it is lifted, the
+// larger it is drawn and the further its shadow falls behind it.
+func drawLiftedPiece(screen *ebiten.Image, pieceColor color.Color, square int, x, y, lift float64) {
+	if pieceColor == nil {
+		return // Don't draw empty cells
 	}
+	scale := 1 + config.SwapLiftScale*lift
+	size := float64(square) * scale
+	left := x - (size-float64(square))/2 // Grow around the center of the tile
+	top := y - (size-float64(square))/2
+	shadow := shadowOffset + config.SwapLiftShadow*lift
+	vector.DrawFilledRect(screen, float32(left+shadow), float32(top+shadow), float32(size), float32(size), config.ShadowColor, false)
 
-	shadowOffset := 2
-	cx := float32(x + float64(config.SquareSize)/2)
-	cy := float32(y + float64(config.SquareSize)/2)
-	r := float32(config.SquareSize / 2)
-	vector.DrawFilledCircle(screen, cx+float32(shadowOffset), cy+float32(shadowOffset), r, config.ShadowColor, true)
-	vector.DrawFilledCircle(screen, cx, cy, r, pieceColor, true)
-	accentR := float32(config.SquareSize / 8)
-	vector.DrawFilledCircle(screen, cx, cy, accentR, accentColor, true)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(left, top)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(sprite(pieceColor, spriteHovered, square), op)
 }
 
 //go:noinline
-func drawUI(screen *ebiten.Image, score, maxScore, moveCount int, scoreHistory []int) {
-	// The UI area is the space above the grid. We'll have a status bar and a graph area.
-	const statusBarHeight = 30 // Height for the text labels at the top.
+func drawUI(screen *ebiten.Image, l layout.Layout, status scene.Status, scoreHistory []int) {
+	// The UI consists of the status bar at the top and the score graph, placed by the layout.
 	uiSideMargin := 20
 
 	// --- Draw text labels at the very top of the screen ---
@@ -180,33 +355,54 @@ func drawUI(screen *ebiten.Image, score, maxScore, moveCount int, scoreHistory [
 	// We get the text height once and use it for all labels.
 	textBounds, _ := font.BoundString(config.STextFace, "M") // Get height of a sample character
 	textH := (textBounds.Max.Y - textBounds.Min.Y).Ceil()
-	textY := (statusBarHeight-textH)/2 + textH
+	bar := l.StatusBar
+	textY := bar.Min.Y + (bar.Dy()-textH)/2 + textH
 
 	// Max Score (Top-Left)
-	maxScoreStr := fmt.Sprintf("Max: %d", maxScore)
-	text.Draw(screen, maxScoreStr, config.STextFace, uiSideMargin, textY, config.Black)
+	maxScoreStr := fmt.Sprintf("Max: %d", status.MaxScore)
+	text.Draw(screen, maxScoreStr, config.STextFace, bar.Min.X+uiSideMargin, textY, config.Black)
 
 	// Current Score (Center-Left)
-	scoreStr := fmt.Sprintf("Score: %d", score)
+	scoreStr := fmt.Sprintf("Score: %d", status.Score)
 	scoreBounds, _ := font.BoundString(config.STextFace, scoreStr)
 	scoreW := (scoreBounds.Max.X - scoreBounds.Min.X).Ceil()
-	scoreX := (config.ScreenWidth - scoreW) / 2
+	scoreX := bar.Min.X + (bar.Dx()-scoreW)/2
 	text.Draw(screen, scoreStr, config.STextFace, scoreX, textY, config.Black)
 
-	// Move Counter (Bottom-Right)
-	moveCountStr := fmt.Sprintf("Moves: %d", moveCount)
+	// Move Counter (Bottom-Right). In the distance-weighted swap mode this is the total cost.
+	// In a challenge, the move budget is shown next to it.
+	moveCountStr := fmt.Sprintf("Moves: %d", status.MoveCost)
+	if status.Budget > 0 {
+		moveCountStr = fmt.Sprintf("Moves: %d / %d", status.MoveCost, status.Budget)
+	}
 	moveBounds, _ := font.BoundString(config.STextFace, moveCountStr)
 	moveW := (moveBounds.Max.X - moveBounds.Min.X).Ceil()
-	moveX := config.ScreenWidth - moveW - uiSideMargin
+	moveX := bar.Max.X - moveW - uiSideMargin
 	text.Draw(screen, moveCountStr, config.STextFace, moveX, textY, config.Black)
 
-	// --- Draw the score graph below the status bar ---
-	graphX := 0
-	graphY := statusBarHeight
-	graphWidth := config.ScreenWidth
-	const graphBottomMargin = 20 // Space between graph and board
-	graphHeight := config.GridOriginY - statusBarHeight - graphBottomMargin
-	drawScoreGraph(screen, scoreHistory, maxScore, graphX, graphY, graphWidth, graphHeight)
+	// Countdown (left of the move counter). It turns red for the last ten seconds.
+	if status.TimeLeft >= 0 {
+		timeStr := scene.FormatTime(status.TimeLeft)
+		timeBounds, _ := font.BoundString(config.STextFace, timeStr)
+		timeW := (timeBounds.Max.X - timeBounds.Min.X).Ceil()
+		timeColor := config.Black
+		if status.TimeLeft <= 10 {
+			timeColor = config.Red
+		}
+		text.Draw(screen, timeStr, config.STextFace, moveX-timeW-uiSideMargin, textY, timeColor)
+	}
+
+	// Completed boards of an endless session (left of the move counter)
+	if status.Boards > 0 {
+		boardsStr := fmt.Sprintf("Boards: %d", status.Boards)
+		boardsBounds, _ := font.BoundString(config.STextFace, boardsStr)
+		boardsW := (boardsBounds.Max.X - boardsBounds.Min.X).Ceil()
+		text.Draw(screen, boardsStr, config.STextFace, moveX-boardsW-uiSideMargin, textY, config.Black)
+	}
+
+	// --- Draw the score graph below the status bar, or beside the board in landscape ---
+	graph := l.Graph
+	drawScoreGraph(screen, scoreHistory, status.MaxScore, graph.Min.X, graph.Min.Y, graph.Dx(), graph.Dy())
 }
 
 //go:noinline
@@ -279,14 +475,17 @@ func drawScoreGraph(screen *ebiten.Image, history []int, maxScore, x, y, width,
 	}
 }
 
-//go:noinline
-func drawStoneDistribution(screen *ebiten.Image, counts map[color.Color]int) {
-	// A struct to hold color and count for sorting
-	type colorCount struct {
-		Color color.Color
-		Count int
-	}
+// distributionItem is the area covered by one color in the stone distribution.
+type distributionItem struct {
+	color      color.Color
+	count      int
+	x, y, w, h int
+}
 
+// distributionItems returns the areas of the colors in the stone distribution
+// of the layout l, in the order they are drawn. It serves both drawing and
+// click and hover detection, so that both always use the same geometry.
+func distributionItems(l layout.Layout, breakdown map[color.Color]scoring.ColorBreakdown, hasShapes bool) []distributionItem {
 	// Helper function to convert a color to a comparable string for stable sorting
 	colorToString := func(c color.Color) string {
 		r, g, b, a := c.RGBA()
@@ -295,48 +494,99 @@ func drawStoneDistribution(screen *ebiten.Image, counts map[color.Color]int) {
 	}
 
 	// Convert map to a slice for stable, sorted display
-	var sortedCounts []colorCount
-	for c, n := range counts {
-		sortedCounts = append(sortedCounts, colorCount{Color: c, Count: n})
+	var items []distributionItem
+	for c, b := range breakdown {
+		items = append(items, distributionItem{color: c, count: b.Items})
 	}
 
 	// Sort by count (descending), then by color (ascending) for a stable order.
 	// This prevents flickering when counts are equal.
-	sort.Slice(sortedCounts, func(i, j int) bool {
-		if sortedCounts[i].Count != sortedCounts[j].Count {
-			return sortedCounts[i].Count > sortedCounts[j].Count
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].count != items[j].count {
+			return items[i].count > items[j].count
 		}
-		return colorToString(sortedCounts[i].Color) < colorToString(sortedCounts[j].Color)
+		return colorToString(items[i].color) < colorToString(items[j].color)
 	})
 
-	// --- Drawing constants ---
-	// Define the area below the board for the miniatures.
-	areaTopY := config.GridOriginY + config.GridHeight
-	areaBottomY := config.ScreenHeight
-	areaHeight := areaBottomY - areaTopY
+	// The area for the miniatures is below the board, or beside it in landscape.
+	area := l.Distribution
+	numColumns := 4
+	itemWidth := distributionItemWidth(hasShapes)
 
+	// Center the block of miniatures both horizontally and vertically in the area.
+	blockStartX := area.Min.X + (area.Dx()-(numColumns*itemWidth))/2
+	blockStartY := area.Min.Y + (area.Dy()-((len(items)+numColumns-1)/numColumns*distributionItemHeight))/2
+
+	for i := range items {
+		items[i].x = blockStartX + i%numColumns*itemWidth
+		items[i].y = blockStartY + i/numColumns*distributionItemHeight
+		items[i].w = itemWidth
+		items[i].h = distributionItemHeight
+	}
+	return items
+}
+
+// distributionItemHeight is the height of one row of the stone distribution.
+const distributionItemHeight = 30
+
+// distributionItemWidth returns the width of one "icon + text + points" block
+// of the stone distribution, which leaves room for a shape icon if there are
+// shapes.
+func distributionItemWidth(hasShapes bool) int {
+	if hasShapes {
+		return 145
+	}
+	return 120
+}
+
+// DistributionColorAt returns the color whose entry in the stone distribution
+// of the layout l is at the given screen position, or nil if there is none.
+func DistributionColorAt(l layout.Layout, breakdown map[color.Color]scoring.ColorBreakdown, shapes map[tiles.Cell]scoring.Shape, mx, my int) color.Color {
+	for _, item := range distributionItems(l, breakdown, shapes != nil) {
+		if mx >= item.x && mx < item.x+item.w && my >= item.y && my < item.y+item.h {
+			return item.color
+		}
+	}
+	return nil
+}
+
+// shapesByColor keys the required shapes by the colors of their cells in the
+// palette. It returns nil if there are no shapes.
+func shapesByColor(shapes map[tiles.Cell]scoring.Shape, palette []color.Color) map[color.Color]scoring.Shape {
+	if shapes == nil {
+		return nil
+	}
+	byColor := make(map[color.Color]scoring.Shape, len(shapes))
+	for cell, shape := range shapes {
+		byColor[cell.Color(palette)] = shape
+	}
+	return byColor
+}
+
+//go:noinline
+func drawStoneDistribution(screen *ebiten.Image, l layout.Layout, breakdown map[color.Color]scoring.ColorBreakdown, shapes map[color.Color]scoring.Shape, highlight color.Color) {
+	// --- Drawing constants ---
 	miniatureSize := 24
-	itemHeight := 30
 	textMarginLeft := 10
-	numColumns := 4
-	itemWidth := 80 // Width for one "icon + text" block
-
-	// Center the block of miniatures both horizontally and vertically in the space below the board.
-	blockStartX := (config.ScreenWidth - (numColumns * itemWidth)) / 2
-	blockStartY := areaTopY + (areaHeight-((len(sortedCounts)+numColumns-1)/numColumns*itemHeight))/2
+	shapeMarginLeft := 30
+	pointsMarginLeft := 30
+	if shapes != nil {
+		pointsMarginLeft += shapeMarginLeft
+	}
 
-	for i, item := range sortedCounts {
-		col := i % numColumns
-		row := i / numColumns
+	for _, item := range distributionItems(l, breakdown, shapes != nil) {
+		x, y := item.x, item.y
 
-		x := blockStartX + col*itemWidth
-		y := blockStartY + row*itemHeight
+		// Frame the miniature of the highlighted color
+		if item.color == highlight {
+			vector.StrokeRect(screen, float32(x-3), float32(y-3), float32(miniatureSize+6), float32(miniatureSize+6), 2, config.Black, true)
+		}
 
 		// Draw the color miniature
-		vector.DrawFilledRect(screen, float32(x), float32(y), float32(miniatureSize), float32(miniatureSize), item.Color, true)
+		vector.DrawFilledRect(screen, float32(x), float32(y), float32(miniatureSize), float32(miniatureSize), item.color, true)
 
 		// Draw the accent color on top of the miniature
-		accentColor, ok := config.AccentColors[item.Color]
+		accentColor, ok := config.AccentColors[item.color]
 		if !ok {
 			accentColor = config.White // Default accent
 		}
@@ -348,7 +598,7 @@ func drawStoneDistribution(screen *ebiten.Image, counts map[color.Color]int) {
 		vector.DrawFilledRect(screen, accentX, accentY, accentSize, accentSize, accentColor, true)
 
 		// Draw the count text
-		countStr := fmt.Sprintf("%d", item.Count)
+		countStr := fmt.Sprintf("%d", item.count)
 		textBounds, _ := font.BoundString(config.MTextFace, countStr)
 		textH := (textBounds.Max.Y - textBounds.Min.Y).Ceil()
 		textX := x + miniatureSize + textMarginLeft
@@ -356,20 +606,152 @@ func drawStoneDistribution(screen *ebiten.Image, counts map[color.Color]int) {
 		textY := y + (miniatureSize-textH)/2 + textH - 2
 
 		text.Draw(screen, countStr, config.MTextFace, textX, textY, config.Black)
+
+		// Draw the shape the color has to form, if any
+		if shape, ok := shapes[item.color]; ok {
+			drawShapeIcon(screen, shape, item.color, textX+shapeMarginLeft, y, miniatureSize)
+		}
+
+		drawColorAnnotations(screen, breakdown[item.color], accentColor, x, y, miniatureSize, textX+pointsMarginLeft, textY)
 	}
 }
 
-var (
-	shareCodeBoundsX int
-	shareCodeBoundsY int
-	shareCodeBoundsW int
-	shareCodeBoundsH int
-)
+// drawColorAnnotations shows how a color scores: a check mark on its miniature
+// once it reaches its best points, the number of groups if it is split, and
+// its current and best points next to the count.
+func drawColorAnnotations(screen *ebiten.Image, b scoring.ColorBreakdown, accentColor color.Color, x, y, size, pointsX, pointsY int) {
+	fx, fy, fs := float32(x), float32(y), float32(size)
+	switch {
+	case b.MaxPoints > 0 && b.Points >= b.MaxPoints:
+		vector.StrokeLine(screen, fx+fs*0.25, fy+fs*0.55, fx+fs*0.45, fy+fs*0.75, 3, accentColor, true)
+		vector.StrokeLine(screen, fx+fs*0.45, fy+fs*0.75, fx+fs*0.8, fy+fs*0.3, 3, accentColor, true)
+	case b.Kind == scoring.KindSplit:
+		splitStr := fmt.Sprintf("%d", len(b.Groups))
+		bounds, _ := font.BoundString(config.XSTextFace, splitStr)
+		textW := (bounds.Max.X - bounds.Min.X).Ceil()
+		text.Draw(screen, splitStr, config.XSTextFace, x+size-textW-3, y+size-3, accentColor)
+	}
+
+	pointsStr := fmt.Sprintf("%d/%d", b.Points, b.MaxPoints)
+	text.Draw(screen, pointsStr, config.XSTextFace, pointsX, pointsY, config.Black)
+}
+
+// drawShapeIcon draws a small picture of a shape, scaled to fit into a square
+// of the given size.
+func drawShapeIcon(screen *ebiten.Image, shape scoring.Shape, shapeColor color.Color, x, y, size int) {
+	width, height := shape.Bounds()
+	cells := width
+	if height > cells {
+		cells = height
+	}
+	cellSize := float32(size) / float32(cells)
+
+	// Center the shape within the square
+	offsetX := float32(x) + (float32(size)-float32(width)*cellSize)/2
+	offsetY := float32(y) + (float32(size)-float32(height)*cellSize)/2
+	for _, cell := range shape.Cells {
+		cx := offsetX + float32(cell.C)*cellSize
+		cy := offsetY + float32(cell.R)*cellSize
+		vector.DrawFilledRect(screen, cx, cy, cellSize-1, cellSize-1, shapeColor, true)
+	}
+}
+
+// DrawResults renders the end-of-game screen on top of the board.
+// The title is drawn large, followed by one line of text per entry.
+func DrawResults(screen *ebiten.Image, l layout.Layout, title string, lines []string) {
+	// Dim the board behind the results panel
+	grid := l.Board
+	vector.DrawFilledRect(screen, float32(grid.Min.X), float32(grid.Min.Y), float32(grid.Dx()), float32(grid.Dy()), config.ShadowColor, false)
+
+	const (
+		panelPadding = 24
+		lineHeight   = 28
+	)
+	panelW := grid.Dx() - 2*panelPadding
+	panelH := 2*panelPadding + lineHeight*(len(lines)+2)
+	panelX := grid.Min.X + panelPadding
+	panelY := grid.Min.Y + (grid.Dy()-panelH)/2
+	vector.DrawFilledRect(screen, float32(panelX), float32(panelY), float32(panelW), float32(panelH), config.BackgroundColor, false)
+
+	// drawCentered draws a line of text horizontally centered in the panel.
+	drawCentered := func(str string, face font.Face, y int) {
+		bounds, _ := font.BoundString(face, str)
+		textW := (bounds.Max.X - bounds.Min.X).Ceil()
+		text.Draw(screen, str, face, panelX+(panelW-textW)/2, y, config.Black)
+	}
+
+	y := panelY + panelPadding + lineHeight
+	drawCentered(title, config.MTextFace, y)
+	y += lineHeight
+	for _, line := range lines {
+		y += lineHeight
+		drawCentered(line, config.STextFace, y)
+	}
+}
+
+// settingsLayout returns the position of the settings panel and of its first
+// option for the given number of options. Options are stacked vertically.
+func settingsLayout(l layout.Layout, numOptions int) (panelX, panelY, panelW, panelH, optionsY, optionHeight int) {
+	const panelPadding = 24
+	optionHeight = 40
+	grid := l.Board
+	panelW = grid.Dx() - 2*panelPadding
+	panelH = 2*panelPadding + optionHeight*(numOptions+1)
+	panelX = grid.Min.X + panelPadding
+	panelY = grid.Min.Y + (grid.Dy()-panelH)/2
+	optionsY = panelY + panelPadding + optionHeight
+	return panelX, panelY, panelW, panelH, optionsY, optionHeight
+}
+
+// DrawSettings renders the settings menu on top of the board. Every option is
+// drawn as a button below the title; the button of the focused option, which
+// the keyboard or a gamepad chooses, has a thicker frame.
+func DrawSettings(screen *ebiten.Image, l layout.Layout, title string, options []string, focus int) {
+	// Dim the board behind the settings panel
+	grid := l.Board
+	vector.DrawFilledRect(screen, float32(grid.Min.X), float32(grid.Min.Y), float32(grid.Dx()), float32(grid.Dy()), config.ShadowColor, false)
+
+	panelX, panelY, panelW, panelH, optionsY, optionHeight := settingsLayout(l, len(options))
+	vector.DrawFilledRect(screen, float32(panelX), float32(panelY), float32(panelW), float32(panelH), config.BackgroundColor, false)
+
+	// drawCentered draws a line of text centered in a row of the panel.
+	drawCentered := func(str string, face font.Face, rowY int) {
+		bounds, _ := font.BoundString(face, str)
+		textW := (bounds.Max.X - bounds.Min.X).Ceil()
+		textH := (bounds.Max.Y - bounds.Min.Y).Ceil()
+		text.Draw(screen, str, face, panelX+(panelW-textW)/2, rowY+(optionHeight+textH)/2, config.Black)
+	}
+
+	drawCentered(title, config.MTextFace, optionsY-optionHeight)
+	for i, option := range options {
+		y := optionsY + i*optionHeight
+		strokeWidth := float32(1)
+		if i == focus {
+			strokeWidth = 3
+		}
+		vector.StrokeRect(screen, float32(panelX+optionHeight/2), float32(y+4), float32(panelW-optionHeight), float32(optionHeight-8), strokeWidth, config.Black, false)
+		drawCentered(option, config.STextFace, y)
+	}
+}
+
+// SettingsOptionAt returns the index of the settings option at the given
+// screen position in the layout l, or -1 if there is none.
+func SettingsOptionAt(l layout.Layout, mx, my, numOptions int) int {
+	panelX, _, panelW, _, optionsY, optionHeight := settingsLayout(l, numOptions)
+	if mx < panelX || mx >= panelX+panelW || my < optionsY {
+		return -1
+	}
+	if i := (my - optionsY) / optionHeight; i < numOptions {
+		return i
+	}
+	return -1
+}
 
 // DrawSharingUI renders the share code and copy feedback.
-func DrawSharingUI(screen *ebiten.Image, code, feedback string, isCustom bool) {
+func DrawSharingUI(screen *ebiten.Image, l layout.Layout, code, feedback string, isCustom bool) {
 	// --- Positioning ---
-	y := config.ScreenHeight - 15 // Position near the bottom, moved down for more spacing
+	area := l.ShareCode
+	y := shareCodeBaseline(l)
 	textColor := config.Black
 
 	// If it's a custom board, use a different color to indicate it.
@@ -389,21 +771,28 @@ func DrawSharingUI(screen *ebiten.Image, code, feedback string, isCustom bool) {
 	// Calculate text dimensions to center it
 	bounds, _ := font.BoundString(config.XSTextFace, displayStr)
 	textW := (bounds.Max.X - bounds.Min.X).Ceil()
-	textH := (bounds.Max.Y - bounds.Min.Y).Ceil()
-	x := (config.ScreenWidth - textW) / 2
-
-	// Store the bounding box of the share code for click detection
-	if feedback == "" {
-		shareCodeBoundsX = x
-		shareCodeBoundsY = y - textH
-		shareCodeBoundsW = textW
-		shareCodeBoundsH = textH + 5 // A little extra height for easier clicking
-	}
+	x := area.Min.X + (area.Dx()-textW)/2
 
 	text.Draw(screen, displayStr, config.XSTextFace, x, y, textColor)
 }
 
-// IsShareCodeClicked checks if the mouse click is within the share code's bounding box.
-func IsShareCodeClicked(mx, my int, code string) bool {
-	return code != "" && mx >= shareCodeBoundsX && mx < shareCodeBoundsX+shareCodeBoundsW && my >= shareCodeBoundsY && my < shareCodeBoundsY+shareCodeBoundsH
+// shareCodeBaseline returns the baseline of the share code in the layout l.
+func shareCodeBaseline(l layout.Layout) int {
+	return l.ShareCode.Max.Y - 15 // Position near the bottom, moved down for more spacing
+}
+
+// shareCodeBounds returns the bounding box of the share code in the layout l.
+func shareCodeBounds(l layout.Layout, code string) image.Rectangle {
+	bounds, _ := font.BoundString(config.XSTextFace, fmt.Sprintf("Share Code: %s", code))
+	textW := (bounds.Max.X - bounds.Min.X).Ceil()
+	textH := (bounds.Max.Y - bounds.Min.Y).Ceil()
+	x := l.ShareCode.Min.X + (l.ShareCode.Dx()-textW)/2
+	y := shareCodeBaseline(l)
+	return image.Rect(x, y-textH, x+textW, y+5) // A little extra height for easier clicking
+}
+
+// IsShareCodeClicked checks if the mouse click is within the bounding box of
+// the share code in the layout l.
+func IsShareCodeClicked(l layout.Layout, mx, my int, code string) bool {
+	return code != "" && image.Pt(mx, my).In(shareCodeBounds(l, code))
 }
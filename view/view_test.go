@@ -0,0 +1,57 @@
+package view
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+	"zenmojo/config"
+	"zenmojo/layout"
+	"zenmojo/scoring"
+)
+
+func TestSwapPath(t *testing.T) {
+	const startX, startY, endX, endY = 10, 20, 66, 20
+
+	start := swapPath(startX, startY, endX, endY, 0, 0)
+	if start != (flight{x1: startX, y1: startY, x2: endX, y2: endY}) {
+		t.Errorf("Expected the tiles to start in their cells without lift, got %+v", start)
+	}
+	end := swapPath(startX, startY, endX, endY, 1, 1)
+	if math.Abs(end.x1-endX) > 1e-9 || math.Abs(end.y1-endY) > 1e-9 ||
+		math.Abs(end.x2-startX) > 1e-9 || math.Abs(end.y2-startY) > 1e-9 || end.lift > 1e-9 {
+		t.Errorf("Expected the tiles to land in each other's cells, got %+v", end)
+	}
+
+	middle := swapPath(startX, startY, endX, endY, 0.5, 0.5)
+	if middle.lift != 1 {
+		t.Errorf("Expected the tiles to be lifted fully midway, got %v", middle.lift)
+	}
+	if middle.x1 != middle.x2 {
+		t.Errorf("Expected the tiles to pass each other midway, got x %v and %v", middle.x1, middle.x2)
+	}
+	if gap := math.Abs(middle.y1 - middle.y2); gap < config.SquareSize*config.SwapArc {
+		t.Errorf("Expected the tiles to travel on opposite arcs, but they are only %v apart", gap)
+	}
+}
+
+func TestHitAreasFollowLayout(t *testing.T) {
+	breakdown := map[color.Color]scoring.ColorBreakdown{config.Palette[0]: {Items: 3}}
+	for _, l := range []layout.Layout{layout.Fit(600, 900), layout.Fit(1600, 900)} {
+		item := distributionItems(l, breakdown, false)[0]
+		if !image.Rect(item.x, item.y, item.x+item.w, item.y+item.h).In(l.Distribution) {
+			t.Errorf("Expected the miniature at (%d, %d) to lie in the distribution area %v", item.x, item.y, l.Distribution)
+		}
+		if c := DistributionColorAt(l, breakdown, nil, item.x+1, item.y+1); c != config.Palette[0] {
+			t.Errorf("Expected the miniature of the color at (%d, %d), got %v", item.x+1, item.y+1, c)
+		}
+
+		bounds := shareCodeBounds(l, "ABC")
+		if !IsShareCodeClicked(l, bounds.Min.X+1, bounds.Min.Y+1, "ABC") {
+			t.Errorf("Expected a click at %v to hit the share code", bounds.Min)
+		}
+		if !bounds.In(l.ShareCode) {
+			t.Errorf("Expected the share code %v to lie in its area %v", bounds, l.ShareCode)
+		}
+	}
+}
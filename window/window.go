@@ -0,0 +1,104 @@
+// Package window remembers the size, position and fullscreen state of the game
+// window between runs. Monitors may have been disconnected, rearranged or
+// replaced in the meantime, so the saved geometry is fitted to the monitors
+// that are there when it is restored.
+package window
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	minWidth  = 200 // Smallest window width that is restored, in device-independent pixels
+	minHeight = 200 // Smallest window height that is restored, in device-independent pixels
+)
+
+// Geometry is the size, position and fullscreen state of the window. Sizes and
+// positions are in device-independent pixels; the position is relative to the
+// top-left corner of the monitor the window is on.
+type Geometry struct {
+	Monitor    string `json:"monitor"` // Name of the monitor the window is on
+	X          int    `json:"x"`
+	Y          int    `json:"y"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Fullscreen bool   `json:"fullscreen"`
+}
+
+// Screen is a monitor the window can be restored on.
+type Screen struct {
+	Name          string
+	Width, Height int // Size in device-independent pixels
+}
+
+// Restore fits the geometry to the given screens, the primary screen first. The
+// window goes back onto the monitor it was on if that is still connected, and
+// otherwise onto the primary one. It is shrunk to fit the monitor and moved
+// completely onto it, so that it can't end up out of reach. screen is the index
+// of the monitor in screens. positioned is false if the monitor was not found;
+// the window should then be placed where the system places new windows. ok is
+// false if there are no screens or the saved size is invalid.
+func (g Geometry) Restore(screens []Screen) (restored Geometry, screen int, positioned, ok bool) {
+	if len(screens) == 0 || g.Width <= 0 || g.Height <= 0 {
+		return Geometry{}, 0, false, false
+	}
+	for i, s := range screens {
+		if s.Name == g.Monitor {
+			screen, positioned = i, true
+			break
+		}
+	}
+	s := screens[screen]
+	restored = g
+	restored.Monitor = s.Name
+	restored.Width = max(min(g.Width, s.Width), min(minWidth, s.Width))
+	restored.Height = max(min(g.Height, s.Height), min(minHeight, s.Height))
+	if !positioned {
+		restored.X, restored.Y = 0, 0
+		return restored, screen, false, true
+	}
+	restored.X = min(max(g.X, 0), s.Width-restored.Width)
+	restored.Y = min(max(g.Y, 0), s.Height-restored.Height)
+	return restored, screen, true, true
+}
+
+// DefaultPath returns the location of the window geometry file in the user's config directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "Zengo", "window.json"), nil
+}
+
+// Load reads the window geometry from a file. ok is false if the file is
+// missing, e.g. on the first run.
+func Load(path string) (g Geometry, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Geometry{}, false, nil
+	}
+	if err != nil {
+		return Geometry{}, false, err
+	}
+	if err := json.Unmarshal(data, &g); err != nil {
+		return Geometry{}, false, err
+	}
+	return g, true, nil
+}
+
+// Save writes the window geometry to a file, creating its directory if needed.
+func (g Geometry) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,104 @@
+package window
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRestore(t *testing.T) {
+	laptop := Screen{Name: "Built-in Display", Width: 1440, Height: 900}
+	monitor := Screen{Name: "DELL U2720Q", Width: 2560, Height: 1440}
+
+	testCases := []struct {
+		name           string
+		saved          Geometry
+		screens        []Screen
+		want           Geometry
+		wantScreen     int
+		wantPositioned bool
+		wantOK         bool
+	}{
+		{
+			name:           "same monitor",
+			saved:          Geometry{Monitor: "DELL U2720Q", X: 100, Y: 50, Width: 600, Height: 980, Fullscreen: true},
+			screens:        []Screen{laptop, monitor},
+			want:           Geometry{Monitor: "DELL U2720Q", X: 100, Y: 50, Width: 600, Height: 980, Fullscreen: true},
+			wantScreen:     1,
+			wantPositioned: true,
+			wantOK:         true,
+		},
+		{
+			name:           "monitor disconnected",
+			saved:          Geometry{Monitor: "DELL U2720Q", X: 1800, Y: 200, Width: 600, Height: 1200},
+			screens:        []Screen{laptop},
+			want:           Geometry{Monitor: "Built-in Display", Width: 600, Height: 900},
+			wantScreen:     0,
+			wantPositioned: false,
+			wantOK:         true,
+		},
+		{
+			name:           "window off the monitor",
+			saved:          Geometry{Monitor: "Built-in Display", X: 1300, Y: -40, Width: 600, Height: 800},
+			screens:        []Screen{laptop},
+			want:           Geometry{Monitor: "Built-in Display", X: 840, Y: 0, Width: 600, Height: 800},
+			wantScreen:     0,
+			wantPositioned: true,
+			wantOK:         true,
+		},
+		{
+			name:           "tiny window",
+			saved:          Geometry{Monitor: "Built-in Display", X: 10, Y: 10, Width: 20, Height: 15},
+			screens:        []Screen{laptop},
+			want:           Geometry{Monitor: "Built-in Display", X: 10, Y: 10, Width: minWidth, Height: minHeight},
+			wantScreen:     0,
+			wantPositioned: true,
+			wantOK:         true,
+		},
+		{
+			name:    "invalid size",
+			saved:   Geometry{Monitor: "Built-in Display", Width: 0, Height: 900},
+			screens: []Screen{laptop},
+		},
+		{
+			name:  "no screens",
+			saved: Geometry{Monitor: "Built-in Display", Width: 600, Height: 900},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, screen, positioned, ok := tc.saved.Restore(tc.screens)
+			if ok != tc.wantOK {
+				t.Fatalf("Expected ok %v, got %v", tc.wantOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if got != tc.want || screen != tc.wantScreen || positioned != tc.wantPositioned {
+				t.Errorf("Expected %+v on screen %d (positioned %v), got %+v on screen %d (positioned %v)",
+					tc.want, tc.wantScreen, tc.wantPositioned, got, screen, positioned)
+			}
+		})
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "window.json")
+
+	// A missing file is not an error, there is just nothing to restore.
+	if _, ok, err := Load(path); err != nil || ok {
+		t.Fatalf("Expected nothing to restore from a missing file, got ok %v and error %v", ok, err)
+	}
+
+	g := Geometry{Monitor: "DELL U2720Q", X: 100, Y: 50, Width: 600, Height: 980, Fullscreen: true}
+	if err := g.Save(path); err != nil {
+		t.Fatalf("Unexpected error saving: %v", err)
+	}
+	loaded, ok, err := Load(path)
+	if err != nil || !ok {
+		t.Fatalf("Unexpected result loading: ok %v, error %v", ok, err)
+	}
+	if loaded != g {
+		t.Errorf("Expected %+v after loading, got %+v", g, loaded)
+	}
+}